@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+	"github.com/thepatrik/jazz/gojazz/pkg/profile"
+)
+
+var (
+	benchCount  int
+	benchWarmup int
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench file [args...]",
+	Short: "Run a script several times and report how long each phase takes",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if benchCount < 1 {
+			fmt.Println("--count must be at least 1")
+			os.Exit(1)
+		}
+
+		file := args[0]
+		b, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Printf("could not read file %s\n", err)
+			os.Exit(1)
+		}
+
+		for n := 0; n < benchWarmup; n++ {
+			benchRun(string(b), args[1:])
+		}
+		runs := make([]benchTimes, benchCount)
+		for n := range runs {
+			runs[n] = benchRun(string(b), args[1:])
+		}
+
+		fmt.Printf("%s: %d runs\n", file, benchCount)
+		fmt.Printf("%-9s %12s %12s %12s %12s\n", "", "mean", "stddev", "min", "max")
+		for phase, name := range benchPhases {
+			times := make([]time.Duration, len(runs))
+			for n, run := range runs {
+				times[n] = run[phase]
+			}
+			mean, stddev, min, max := benchStats(times)
+			fmt.Printf("%-9s %12s %12s %12s %12s\n", name, profile.Round(mean), profile.Round(stddev), profile.Round(min), profile.Round(max))
+		}
+	},
+}
+
+func init() {
+	benchCmd.Flags().SetInterspersed(false)
+	benchCmd.Flags().IntVarP(&benchCount, "count", "n", 10, "number of timed runs.")
+	benchCmd.Flags().IntVar(&benchWarmup, "warmup", 1, "number of untimed runs before the timed ones.")
+	jazzCmd.AddCommand(benchCmd)
+}
+
+var benchPhases = []string{"scan", "parse", "resolve", "interpret", "total"}
+
+// benchTimes is how long each of benchPhases took in a run.
+type benchTimes [5]time.Duration
+
+// benchRun runs source once in a fresh interpreter, discarding its output.
+// Creating the interpreter counts towards resolving. The program stops at the
+// first error, since timings of a failing run mean little.
+func benchRun(source string, args []string) benchTimes {
+	var times benchTimes
+	fail := func(err error) {
+		os.Exit(jazz.ReportError(os.Stderr, err))
+	}
+
+	start := time.Now()
+	lap := func(phase int) {
+		times[phase] = time.Since(start)
+		for n := 0; n < phase; n++ {
+			times[phase] -= times[n]
+		}
+	}
+
+	tokens, err := jazz.NewScanner(source).ScanTokens()
+	if err != nil {
+		fail(err)
+	}
+	lap(0)
+
+	parser := jazz.NewParser(tokens, jazz.WithErrOutput(os.Stderr))
+	stmts, err := parser.Parse()
+	if err != nil {
+		fail(err)
+	}
+	if parser.HasErrors() {
+		fail(jazz.ErrSyntax)
+	}
+	lap(1)
+
+	interpreter := jazz.NewInterpreter(append(interpreterOpts(), jazz.WithArgs(args), jazz.WithStdout(io.Discard))...)
+	if err := jazz.NewResolver(interpreter).Resolve(stmts); err != nil {
+		fail(err)
+	}
+	lap(2)
+
+	if err := interpreter.Interpret(stmts); err != nil {
+		fail(&jazz.RuntimeError{Err: err})
+	}
+	lap(3)
+
+	times[4] = time.Since(start)
+	return times
+}
+
+// benchStats returns the mean, sample standard deviation, minimum and maximum
+// of times.
+func benchStats(times []time.Duration) (mean, stddev, min, max time.Duration) {
+	var sum float64
+	min, max = times[0], times[0]
+	for _, t := range times {
+		sum += float64(t)
+		if t < min {
+			min = t
+		}
+		if t > max {
+			max = t
+		}
+	}
+	avg := sum / float64(len(times))
+
+	if len(times) > 1 {
+		var squares float64
+		for _, t := range times {
+			squares += (float64(t) - avg) * (float64(t) - avg)
+		}
+		stddev = time.Duration(math.Sqrt(squares / float64(len(times)-1)))
+	}
+	return time.Duration(avg), stddev, min, max
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+var checkJSON bool
+
+var checkCmd = &cobra.Command{
+	Use:   "check paths...",
+	Short: "Parse and resolve scripts without running them",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		diagnostics := []*diagnostic{}
+		for _, path := range args {
+			files, err := jazzFiles(path)
+			if err != nil {
+				fmt.Printf("could not read %s\n", err)
+				os.Exit(1)
+			}
+
+			for _, file := range files {
+				diagnostics = append(diagnostics, checkFile(file)...)
+			}
+		}
+
+		if checkJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(diagnostics); err != nil {
+				fmt.Printf("could not encode diagnostics %s\n", err)
+				os.Exit(1)
+			}
+		} else {
+			for _, d := range diagnostics {
+				fmt.Fprintln(os.Stderr, d)
+			}
+		}
+
+		for _, d := range diagnostics {
+			if d.Severity == severityError {
+				os.Exit(65)
+			}
+		}
+	},
+}
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "print diagnostics as JSON.")
+	jazzCmd.AddCommand(checkCmd)
+}
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+type diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (d *diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+}
+
+func newDiagnostic(file, severity string, err error) *diagnostic {
+	msg := err.Error()
+	switch e := err.(type) {
+	case *jazz.ScannerError:
+		msg = e.Message
+	case *jazz.ParserError:
+		msg = e.Message
+	case *jazz.ResolverError:
+		msg = e.Message
+	}
+
+	return &diagnostic{File: file, Line: jazz.ErrorLine(err), Severity: severity, Message: msg}
+}
+
+// jazzFiles returns path if it is a file, or every .jz file below it if it is
+// a directory.
+func jazzFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(file) == ".jz" {
+			files = append(files, file)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// checkFile scans, parses and resolves file, returning every diagnostic found.
+// Resolution is skipped when the file has syntax errors.
+func checkFile(file string) []*diagnostic {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return []*diagnostic{{File: file, Severity: severityError, Message: err.Error()}}
+	}
+
+	diagnostics := []*diagnostic{}
+	addErrs := func(severity string, err error) {
+		if list, ok := err.(jazz.ErrorList); ok {
+			for _, err := range list {
+				diagnostics = append(diagnostics, newDiagnostic(file, severity, err))
+			}
+		} else if err != nil {
+			diagnostics = append(diagnostics, newDiagnostic(file, severity, err))
+		}
+	}
+
+	tokens, err := jazz.NewScanner(string(b)).ScanTokens()
+	addErrs(severityError, err)
+
+	parser := jazz.NewParser(tokens, jazz.WithErrOutput(io.Discard), jazz.WithRecovery(true))
+	stmts, err := parser.Parse()
+	addErrs(severityError, err)
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	resolver := jazz.NewResolver(jazz.NewInterpreter(interpreterOpts()...))
+	addErrs(severityError, resolver.Resolve(stmts))
+	for _, warning := range resolver.Warnings {
+		addErrs(severityWarning, warning)
+	}
+
+	return diagnostics
+}
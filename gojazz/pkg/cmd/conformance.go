@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/conformance"
+)
+
+var (
+	conformanceExec    string
+	conformanceVerbose bool
+)
+
+var conformanceCmd = &cobra.Command{
+	Use:   "conformance paths...",
+	Short: "Check an implementation against scripts annotated with // expect comments",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var runner conformance.Runner = &conformance.Interpreter{Options: interpreterOpts()}
+		if conformanceExec != "" {
+			runner = &conformance.Command{Args: strings.Fields(conformanceExec)}
+		}
+
+		passed, failed := 0, 0
+		for _, path := range args {
+			files, err := jazzFiles(path)
+			if err != nil {
+				fmt.Printf("could not read %s\n", err)
+				os.Exit(1)
+			}
+
+			for _, file := range files {
+				mismatches, err := conform(runner, file)
+				if err != nil {
+					fmt.Printf("could not run %s\n", err)
+					os.Exit(1)
+				}
+
+				if len(mismatches) == 0 {
+					passed++
+					if conformanceVerbose {
+						fmt.Printf("PASS %s\n", file)
+					}
+					continue
+				}
+				failed++
+				fmt.Printf("FAIL %s\n", file)
+				for _, m := range mismatches {
+					fmt.Printf("    %s\n", m)
+				}
+			}
+		}
+
+		if failed > 0 {
+			fmt.Printf("%d passed, %d failed\n", passed, failed)
+			os.Exit(1)
+		}
+		fmt.Printf("all %d passed\n", passed)
+	},
+}
+
+func init() {
+	conformanceCmd.Flags().StringVar(&conformanceExec, "exec", "", "check this command, such as \"../cjazz/bin/main.exe\", instead of the Go interpreter. It is run with the path of each script.")
+	conformanceCmd.Flags().BoolVarP(&conformanceVerbose, "verbose", "v", false, "list passing scripts too.")
+	jazzCmd.AddCommand(conformanceCmd)
+}
+
+func conform(runner conformance.Runner, file string) ([]conformance.Mismatch, error) {
+	c, err := conformance.Load(file)
+	if err != nil {
+		return nil, err
+	}
+	result, err := runner.Run(c)
+	if err != nil {
+		return nil, err
+	}
+	return conformance.Check(c, result), nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/cover"
+)
+
+var (
+	coverEnabled bool
+	coverProfile string
+	coverHTML    string
+
+	// coverage collects the coverage of every file run, when asked for.
+	coverage *cover.Coverage
+)
+
+func addCoverFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&coverEnabled, "cover", false, "print the line and branch coverage of each file.")
+	cmd.Flags().StringVar(&coverProfile, "coverprofile", "", "write the coverage as an lcov tracefile to this file; implies --cover.")
+	cmd.Flags().StringVar(&coverHTML, "coverhtml", "", "write the coverage as an annotated HTML page to this file; implies --cover.")
+}
+
+// writeCoverage prints the coverage summary to stderr and writes the
+// requested reports.
+func writeCoverage() {
+	if coverage == nil {
+		return
+	}
+
+	if err := coverage.WriteSummary(os.Stderr); err != nil {
+		fmt.Printf("could not write coverage %s\n", err)
+		os.Exit(1)
+	}
+	writeReport(coverProfile, coverage.WriteLCOV)
+	writeReport(coverHTML, coverage.WriteHTML)
+}
+
+func writeReport(path string, write func(w io.Writer) error) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.Create(path)
+	if err == nil {
+		err = write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Printf("could not write coverage %s\n", err)
+		os.Exit(1)
+	}
+}
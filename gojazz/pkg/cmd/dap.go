@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/dap"
+)
+
+var dapCmd = &cobra.Command{
+	Use:   "dap",
+	Short: "Run a Debug Adapter Protocol server over stdin and stdout",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := dap.NewServer(os.Stdin, os.Stdout, interpreterOpts()...).Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	jazzCmd.AddCommand(dapCmd)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/debug"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug file [args...]",
+	Short: "Run a script in the step debugger",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		lines, err := cmd.Flags().GetIntSlice("break")
+		if err != nil {
+			fmt.Printf("could not read break flag %s\n", err)
+			os.Exit(1)
+		}
+
+		debugFile(args[0], args[1:], lines)
+	},
+}
+
+func init() {
+	debugCmd.Flags().SetInterspersed(false)
+	debugCmd.Flags().IntSliceP("break", "b", nil, "lines to set breakpoints on before starting.")
+	jazzCmd.AddCommand(debugCmd)
+}
+
+const debugHelp = `commands:
+  b, break LINE     set a breakpoint
+  clear LINE        remove a breakpoint
+  c, continue       run to the next breakpoint
+  s, step           step to the next line, into calls
+  n, next           step to the next line, over calls
+  o, out            run until the current function returns
+  bt, stack         show the call stack
+  f, frame N        select frame N of the call stack
+  l, locals         show the variables of the selected frame
+  g, globals        show the global variables
+  p, print EXPR     evaluate an expression in the selected frame
+  list              show the source around the current line
+  q, quit           stop the program
+  h, help           show this help`
+
+// terminal is the debugger's command line.
+type terminal struct {
+	file   string
+	lines  []string
+	in     *bufio.Reader
+	out    io.Writer
+	dbg    *debug.Debugger
+	frame  int // selected frame, innermost is 0
+	frames []*jazz.Frame
+}
+
+func debugFile(file string, args []string, breakpoints []int) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("could not read file %s\n", err)
+		os.Exit(1)
+	}
+
+	t := &terminal{
+		file:  file,
+		lines: strings.Split(string(b), "\n"),
+		in:    bufio.NewReader(os.Stdin),
+		out:   os.Stdout,
+	}
+	t.dbg = debug.New(t.pause)
+	t.dbg.StopOnEntry = len(breakpoints) == 0
+	t.dbg.SetBreakpoints(breakpoints...)
+
+	// Share one reader with the interpreter, so neither buffers input meant
+	// for the other.
+	interpreter := jazz.NewInterpreter(append(interpreterOpts(), jazz.WithArgs(args), jazz.WithStdin(t.in), jazz.WithHook(t.dbg))...)
+	err = jazz.Run(interpreter, string(b))
+	if errors.Is(err, debug.ErrTerminated) {
+		return
+	}
+	if err != nil {
+		os.Exit(jazz.ReportError(interpreter.Stderr(), err))
+	}
+	fmt.Fprintln(t.out, "program exited")
+}
+
+// pause shows where the program stopped and reads commands until one resumes
+// it.
+func (t *terminal) pause(stop *debug.Stop) debug.Action {
+	t.frame, t.frames = 0, stop.Frames()
+	fmt.Fprintf(t.out, "%s:%d (%s) in %s\n", t.file, stop.Line, stop.Reason, t.frames[0].Name())
+	t.list(stop.Line, 0)
+
+	for {
+		fmt.Fprint(t.out, "(jazz) ")
+		line, err := t.in.ReadString('\n')
+		if err != nil && line == "" {
+			return debug.Terminate
+		}
+
+		command, arg := splitCommand(line)
+		switch command {
+		case "":
+			continue
+		case "c", "continue":
+			return debug.Continue
+		case "s", "step":
+			return debug.StepIn
+		case "n", "next":
+			return debug.StepOver
+		case "o", "out":
+			return debug.StepOut
+		case "q", "quit":
+			return debug.Terminate
+		case "b", "break":
+			if n, ok := t.lineArg(arg); ok {
+				t.dbg.AddBreakpoint(n)
+				fmt.Fprintf(t.out, "breakpoint set at %s:%d\n", t.file, n)
+			}
+		case "clear":
+			if n, ok := t.lineArg(arg); ok && !t.dbg.ClearBreakpoint(n) {
+				fmt.Fprintf(t.out, "no breakpoint at line %d\n", n)
+			}
+		case "bt", "stack":
+			t.stack()
+		case "f", "frame":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 || n >= len(t.frames) {
+				fmt.Fprintf(t.out, "no frame %q\n", arg)
+				continue
+			}
+			t.frame = n
+			t.list(t.frames[n].Line, 0)
+		case "l", "locals":
+			t.variables(debug.Locals(stop.Interpreter, t.frames[t.frame]))
+		case "g", "globals":
+			t.variables(debug.Globals(stop.Interpreter))
+		case "p", "print":
+			val, err := stop.Interpreter.Evaluate(arg, t.frames[t.frame].Env)
+			if err != nil {
+				fmt.Fprintln(t.out, err)
+				continue
+			}
+			fmt.Fprintln(t.out, jazz.Repr(val))
+		case "list":
+			t.list(t.frames[t.frame].Line, 5)
+		case "h", "help":
+			fmt.Fprintln(t.out, debugHelp)
+		default:
+			fmt.Fprintf(t.out, "unknown command %q, type \"help\" for a list\n", command)
+		}
+	}
+}
+
+func splitCommand(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	command, arg, _ := strings.Cut(line, " ")
+	return command, strings.TrimSpace(arg)
+}
+
+func (t *terminal) lineArg(arg string) (int, bool) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(t.lines) {
+		fmt.Fprintf(t.out, "no line %q in %s\n", arg, t.file)
+		return 0, false
+	}
+	return n, true
+}
+
+// list prints line with context lines around it, marking line.
+func (t *terminal) list(line, context int) {
+	for n := line - context; n <= line+context; n++ {
+		if n < 1 || n > len(t.lines) {
+			continue
+		}
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(t.out, "%s %4d | %s\n", marker, n, strings.TrimRight(t.lines[n-1], "\r"))
+	}
+}
+
+func (t *terminal) stack() {
+	for n, frame := range t.frames {
+		marker := " "
+		if n == t.frame {
+			marker = "*"
+		}
+		fmt.Fprintf(t.out, "%s #%d %s at %s:%d\n", marker, n, frame.Name(), t.file, frame.Line)
+	}
+}
+
+func (t *terminal) variables(vars []debug.Variable) {
+	if len(vars) == 0 {
+		fmt.Fprintln(t.out, "no variables")
+	}
+	for _, v := range vars {
+		fmt.Fprintf(t.out, "%s = %s\n", v.Name, jazz.Repr(v.Value))
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// diff returns a unified diff between two versions of file.
+func diff(file, a, b string) string {
+	x := strings.SplitAfter(a, "\n")
+	y := strings.SplitAfter(b, "\n")
+	edits := diffLines(x, y)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", file, file)
+
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+
+		// Grow the hunk until it is followed by more unchanged lines than
+		// twice the context.
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		end := start
+		for n := start; n < len(edits); n++ {
+			if edits[n].op != ' ' {
+				end = n + 1
+			} else if n-end >= 2*diffContext {
+				break
+			}
+		}
+		to := end + diffContext
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		hunk := edits[from:to]
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunk[0].x+1, count(hunk, '-'), hunk[0].y+1, count(hunk, '+'))
+		for _, e := range hunk {
+			line := e.line
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			sb.WriteString(string(e.op) + line)
+		}
+		start = to
+	}
+
+	return sb.String()
+}
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+	x, y int // line indexes in a and b before this edit
+}
+
+// diffLines computes a minimal line edit script using the longest common
+// subsequence of x and y.
+func diffLines(x, y []string) []edit {
+	if len(x) > 0 && x[len(x)-1] == "" {
+		x = x[:len(x)-1]
+	}
+	if len(y) > 0 && y[len(y)-1] == "" {
+		y = y[:len(y)-1]
+	}
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] > lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := []edit{}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			edits = append(edits, edit{op: ' ', line: x[i], x: i, y: j})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{op: '-', line: x[i], x: i, y: j})
+			i++
+		default:
+			edits = append(edits, edit{op: '+', line: y[j], x: i, y: j})
+			j++
+		}
+	}
+
+	return edits
+}
+
+// count returns the number of lines of the old ('-') or new ('+') version in
+// a hunk.
+func count(hunk []edit, side byte) int {
+	n := 0
+	for _, e := range hunk {
+		if e.op == ' ' || e.op == side {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+var (
+	fmtWrite bool
+	fmtDiff  bool
+	fmtCheck bool
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [paths...]",
+	Short: "Format scripts, or standard input when no paths are given",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			b, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("could not read stdin %s\n", err)
+				os.Exit(1)
+			}
+
+			formatted, err := jazz.Format(string(b))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(65)
+			}
+			fmt.Print(formatted)
+			return
+		}
+
+		ok := true
+		for _, path := range args {
+			files, err := jazzFiles(path)
+			if err != nil {
+				fmt.Printf("could not read %s\n", err)
+				os.Exit(1)
+			}
+
+			for _, file := range files {
+				if !formatFile(file) {
+					ok = false
+				}
+			}
+		}
+
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "write the result back to the files.")
+	fmtCmd.Flags().BoolVarP(&fmtDiff, "diff", "d", false, "print a diff instead of the formatted source.")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "list files that are not formatted and exit non-zero.")
+	jazzCmd.AddCommand(fmtCmd)
+}
+
+// formatFile formats file as the flags ask. It reports false when the file
+// could not be formatted, or when --check finds it unformatted.
+func formatFile(file string) bool {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return false
+	}
+
+	source := string(b)
+	formatted, err := jazz.Format(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+		return false
+	}
+
+	changed := formatted != source
+	if fmtCheck {
+		if changed {
+			fmt.Println(file)
+		}
+		return !changed
+	}
+
+	if fmtDiff {
+		if changed {
+			fmt.Print(diff(file, source, formatted))
+		}
+	} else if !fmtWrite {
+		fmt.Print(formatted)
+	}
+
+	if fmtWrite && changed {
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return false
+		}
+		if err := os.WriteFile(file, []byte(formatted), info.Mode()); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return false
+		}
+	}
+
+	return true
+}
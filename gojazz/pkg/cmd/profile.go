@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/profile"
+)
+
+var (
+	profilePath string
+	profileTop  int
+)
+
+// writeProfile writes the folded stacks to the --profile file and the table
+// of the slowest functions and lines to stderr.
+func writeProfile(profiler *profile.Profiler, source string) {
+	profiler.Stop()
+
+	f, err := os.Create(profilePath)
+	if err != nil {
+		fmt.Printf("could not write profile %s\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	if err := profiler.WriteFolded(f); err != nil {
+		fmt.Printf("could not write profile %s\n", err)
+		os.Exit(1)
+	}
+	if err := profiler.WriteTable(os.Stderr, profileTop, source); err != nil {
+		fmt.Printf("could not write profile %s\n", err)
+		os.Exit(1)
+	}
+}
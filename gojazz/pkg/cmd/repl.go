@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+	"github.com/thepatrik/strcolor"
+)
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "Start an interactive session",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		repl()
+	},
+}
+
+func init() {
+	jazzCmd.AddCommand(replCmd)
+}
+
+func repl() {
+	reader := bufio.NewReader(os.Stdin)
+	interpreter := jazz.NewInterpreter(append(interpreterOpts(), jazz.WithRepl(true), jazz.WithStdin(reader))...)
+
+	fmt.Println(strcolor.BrightCyan(fmt.Sprintf("Welcome to Jazz v%s", version)))
+	fmt.Println(strcolor.Cyan("Type \".exit\" to exit."))
+
+	for {
+		fmt.Printf("> ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Printf("could not read line %s\n", err)
+			os.Exit(1)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == ".exit" {
+			break
+		}
+
+		err = jazz.Run(interpreter, line)
+		var exitErr *jazz.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+		if err != nil && err != jazz.ErrSyntax {
+			fmt.Fprintln(interpreter.Stderr(), err)
+		}
+	}
+}
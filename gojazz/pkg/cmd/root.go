@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+const version = "0.0.1"
+
+var (
+	maxCallDepth int
+	capabilities []string
+	root         string
+)
+
+var jazzCmd = &cobra.Command{
+	Use:   "jazz [file] [args...]",
+	Short: "jazz is a gas",
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		for _, capability := range capabilities {
+			if !contains(jazz.CapabilityNames(), capability) {
+				return fmt.Errorf("unknown capability %q", capability)
+			}
+		}
+		return nil
+	},
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			fmt.Printf("could not read file flag %s\n", err)
+			os.Exit(1)
+		}
+
+		if file == "" && len(args) == 0 {
+			repl()
+			return
+		}
+
+		if code := runPath(file, args); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	jazzCmd.Flags().SetInterspersed(false)
+	jazzCmd.PersistentFlags().StringP("file", "f", "", "a file or a directory to parse.")
+	jazzCmd.PersistentFlags().StringSliceVar(&capabilities, "capabilities", jazz.AllCapabilities, fmt.Sprintf("natives granted to scripts, any of %s.", strings.Join(jazz.CapabilityNames(), ", ")))
+	jazzCmd.PersistentFlags().StringVar(&root, "root", "", "directory the file system natives are confined to.")
+	jazzCmd.PersistentFlags().IntVar(&maxCallDepth, "max-call-depth", jazz.DefaultMaxCallDepth, "maximum depth of nested function calls.")
+}
+
+func Execute() {
+	if err := jazzCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func interpreterOpts() []jazz.InterpreterOpt {
+	return []jazz.InterpreterOpt{
+		jazz.WithMaxCallDepth(maxCallDepth),
+		jazz.WithCapabilities(capabilities...),
+		jazz.WithRoot(root),
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
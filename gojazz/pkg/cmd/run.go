@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/cover"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+	"github.com/thepatrik/jazz/gojazz/pkg/profile"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [file] [args...]",
+	Short: "Run a script, or every script in a directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			fmt.Printf("could not read file flag %s\n", err)
+			os.Exit(1)
+		}
+
+		if file == "" && len(args) == 0 {
+			fmt.Println("no file to run")
+			os.Exit(1)
+		}
+
+		if coverEnabled || coverProfile != "" || coverHTML != "" {
+			coverage = cover.New()
+		}
+		code := runPath(file, args)
+		writeCoverage()
+		if code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().StringVar(&profilePath, "profile", "", "write a folded-stack profile to this file and print the slowest functions and lines.")
+	runCmd.Flags().IntVar(&profileTop, "profile-top", 10, "number of functions and lines in the profile table.")
+	addCoverFlags(runCmd)
+	jazzCmd.AddCommand(runCmd)
+}
+
+// runPath runs file, or the first of args when file is empty, passing the
+// remaining args to the script. It returns the status the process should exit
+// with.
+func runPath(file string, args []string) int {
+	if file == "" {
+		file, args = args[0], args[1:]
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		fmt.Printf("could not read file %s\n", err)
+		return 1
+	}
+
+	if info.IsDir() {
+		if profilePath != "" {
+			fmt.Println("--profile needs a single file")
+			return 1
+		}
+		return runFilesInDir(file, args)
+	}
+	return runFile(file, args)
+}
+
+// runFile runs a script, reporting the error it fails with, if any, and
+// returning its exit status.
+func runFile(file string, args []string) int {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("could not read file %s\n", err)
+		return 1
+	}
+
+	options := append(interpreterOpts(), jazz.WithArgs(args))
+	var profiler *profile.Profiler
+	if profilePath != "" {
+		profiler = profile.New()
+		options = append(options, jazz.WithHook(profiler))
+	}
+
+	var covered *cover.File
+	if coverage != nil {
+		covered = coverage.File(file, string(b))
+		options = append(options, jazz.WithHook(covered))
+	}
+
+	interpreter := jazz.NewInterpreter(options...)
+	stmts, err := jazz.Compile(interpreter, string(b))
+	if err == nil {
+		if covered != nil {
+			covered.Add(stmts)
+		}
+		if err = interpreter.Interpret(stmts); err != nil {
+			err = &jazz.RuntimeError{Err: err}
+		}
+	}
+	if profiler != nil {
+		writeProfile(profiler, string(b))
+	}
+	return jazz.ReportError(interpreter.Stderr(), err)
+}
+
+// runFilesInDir runs every script in dir, even after one fails, and returns
+// the first non-zero status they exit with.
+func runFilesInDir(dir string, args []string) int {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jz"))
+	if err != nil {
+		fmt.Printf("could not read files in %s\n", dir)
+		return 1
+	}
+
+	code := 0
+	for _, file := range files {
+		if status := runFile(file, args); status != 0 && code == 0 {
+			code = status
+		}
+	}
+	return code
+}
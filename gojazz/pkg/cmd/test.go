@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thepatrik/jazz/gojazz/pkg/cover"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+var (
+	testRun     string
+	testFormat  string
+	testVerbose bool
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [paths...]",
+	Short: "Run the test_ functions of *_test.jz files",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		pattern, err := regexp.Compile(testRun)
+		if err != nil {
+			fmt.Printf("invalid --run pattern %s\n", err)
+			os.Exit(1)
+		}
+		if testFormat != "text" && testFormat != "tap" && testFormat != "junit" {
+			fmt.Printf("unknown format %q, expected text, tap or junit\n", testFormat)
+			os.Exit(1)
+		}
+		if coverEnabled || coverProfile != "" || coverHTML != "" {
+			coverage = cover.New()
+		}
+
+		files := []string{}
+		for _, path := range args {
+			found, err := testFiles(path)
+			if err != nil {
+				fmt.Printf("could not read %s\n", err)
+				os.Exit(1)
+			}
+			files = append(files, found...)
+		}
+
+		results := []*testResult{}
+		for _, file := range files {
+			for _, result := range testFile(file, pattern) {
+				if testFormat == "text" {
+					result.writeText(os.Stdout, testVerbose)
+				}
+				results = append(results, result)
+			}
+		}
+
+		switch testFormat {
+		case "tap":
+			writeTAP(os.Stdout, results)
+		case "junit":
+			if err := writeJUnit(os.Stdout, results); err != nil {
+				fmt.Printf("could not write results %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		failed := 0
+		for _, result := range results {
+			if result.Failure != "" {
+				failed++
+			}
+		}
+		if testFormat == "text" {
+			if failed > 0 {
+				fmt.Printf("FAIL: %d of %d tests failed\n", failed, len(results))
+			} else {
+				fmt.Printf("ok: %d tests passed\n", len(results))
+			}
+		}
+
+		writeCoverage()
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	testCmd.Flags().StringVar(&testRun, "run", "", "only run tests whose names match this regular expression.")
+	testCmd.Flags().StringVar(&testFormat, "format", "text", "how to report results: text, tap or junit.")
+	testCmd.Flags().BoolVarP(&testVerbose, "verbose", "v", false, "report passing tests and their output too.")
+	addCoverFlags(testCmd)
+	jazzCmd.AddCommand(testCmd)
+}
+
+// testResult is the outcome of a test. A file that cannot be loaded is
+// reported as a failed test without a name.
+type testResult struct {
+	File    string
+	Name    string
+	Line    int    // where the test failed, if known
+	Failure string // empty if the test passed
+	Output  string
+	Elapsed time.Duration
+}
+
+func (r *testResult) title() string {
+	if r.Name == "" {
+		return r.File
+	}
+	return fmt.Sprintf("%s: %s", r.File, r.Name)
+}
+
+// location is where the test failed, such as "math_test.jz:12".
+func (r *testResult) location() string {
+	if r.Line == 0 {
+		return r.File
+	}
+	return fmt.Sprintf("%s:%d", r.File, r.Line)
+}
+
+func (r *testResult) writeText(w io.Writer, verbose bool) {
+	if r.Failure == "" && !verbose {
+		return
+	}
+
+	status := "PASS"
+	if r.Failure != "" {
+		status = "FAIL"
+	}
+	fmt.Fprintf(w, "--- %s: %s (%s)\n", status, r.title(), r.Elapsed.Round(time.Microsecond))
+	if r.Failure != "" {
+		fmt.Fprintf(w, "%s\n", indent(fmt.Sprintf("%s: %s", r.location(), r.Failure), "    "))
+	}
+	if r.Output != "" {
+		fmt.Fprint(w, indent(r.Output, "    | "))
+	}
+}
+
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for n, line := range lines {
+		if line != "" {
+			lines[n] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// testFiles returns path if it is a file, or every *_test.jz file below it if
+// it is a directory.
+func testFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := jazzFiles(path)
+	if err != nil || !info.IsDir() {
+		return files, err
+	}
+
+	tests := []string{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.jz") {
+			tests = append(tests, file)
+		}
+	}
+	return tests, nil
+}
+
+// testFile runs every test in file whose name matches pattern, each in a
+// fresh interpreter that first runs the file's top-level code.
+func testFile(file string, pattern *regexp.Regexp) []*testResult {
+	failed := func(err error) []*testResult {
+		return []*testResult{{File: file, Failure: err.Error()}}
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return failed(err)
+	}
+
+	tokens, err := jazz.NewScanner(string(b)).ScanTokens()
+	if err != nil {
+		return failed(err)
+	}
+	stmts, err := jazz.NewParser(tokens, jazz.WithErrOutput(io.Discard), jazz.WithRecovery(true)).Parse()
+	if err != nil {
+		return failed(err)
+	}
+	if err := jazz.NewResolver(jazz.NewInterpreter(testOpts(io.Discard)...)).Resolve(stmts); err != nil {
+		return failed(err)
+	}
+
+	var covered *cover.File
+	if coverage != nil {
+		covered = coverage.File(file, string(b))
+		covered.Add(stmts)
+	}
+
+	results := []*testResult{}
+	for _, stmt := range stmts {
+		fn, ok := stmt.(*jazz.FuncStmt)
+		if !ok || !strings.HasPrefix(fn.Name.Lexeme, "test_") || !pattern.MatchString(fn.Name.Lexeme) {
+			continue
+		}
+
+		result := &testResult{File: file, Name: fn.Name.Lexeme, Line: fn.Name.Line}
+		if len(fn.Params) > 0 {
+			result.Failure = "test functions take no arguments"
+		} else {
+			runTest(result, stmts, covered)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func testOpts(out io.Writer) []jazz.InterpreterOpt {
+	return append(interpreterOpts(),
+		jazz.WithCapabilities(append(append([]string{}, capabilities...), jazz.CapabilityTest)...),
+		jazz.WithStdout(out),
+		jazz.WithStderr(out))
+}
+
+// runTest runs the file's top-level code and then the test function,
+// recording the outcome in result.
+func runTest(result *testResult, stmts []jazz.Stmt, covered *cover.File) {
+	var out bytes.Buffer
+	options := testOpts(&out)
+	if covered != nil {
+		options = append(options, jazz.WithHook(covered))
+	}
+	interpreter := jazz.NewInterpreter(options...)
+
+	start := time.Now()
+	err := jazz.NewResolver(interpreter).Resolve(stmts)
+	if err == nil {
+		err = interpreter.Interpret(stmts)
+	}
+	if err == nil {
+		fn, _ := interpreter.Globals().Lookup(result.Name)
+		if callable, ok := fn.(jazz.Callable); ok {
+			_, err = interpreter.Call(callable)
+		} else {
+			err = fmt.Errorf("%s is no longer a function", result.Name)
+		}
+	}
+	result.Elapsed = time.Since(start)
+	result.Output = out.String()
+
+	if err == nil {
+		return
+	}
+	result.Line = 0
+	result.Failure = err.Error()
+	var assertion *jazz.AssertionError
+	if errors.As(err, &assertion) {
+		result.Line = assertion.Line
+		result.Failure = assertion.Message
+	}
+}
+
+// writeTAP reports results in the Test Anything Protocol, version 13.
+func writeTAP(w io.Writer, results []*testResult) {
+	fmt.Fprintf(w, "TAP version 13\n1..%d\n", len(results))
+	for n, r := range results {
+		if r.Failure == "" {
+			fmt.Fprintf(w, "ok %d - %s\n", n+1, r.title())
+			continue
+		}
+
+		fmt.Fprintf(w, "not ok %d - %s\n", n+1, r.title())
+		fmt.Fprintf(w, "  ---\n  message: %q\n  at: %q\n", r.Failure, r.location())
+		if r.Output != "" {
+			fmt.Fprintf(w, "  output: %q\n", r.Output)
+		}
+		fmt.Fprintf(w, "  ...\n")
+	}
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     float64      `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit reports results as JUnit XML, one suite per file, as read by most
+// CI servers.
+func writeJUnit(w io.Writer, results []*testResult) error {
+	suites := junitSuites{}
+	for _, r := range results {
+		if len(suites.Suites) == 0 || suites.Suites[len(suites.Suites)-1].Name != r.File {
+			suites.Suites = append(suites.Suites, junitSuite{Name: r.File})
+		}
+		suite := &suites.Suites[len(suites.Suites)-1]
+
+		name := r.Name
+		if name == "" {
+			name = r.File
+		}
+		c := junitCase{Name: name, Classname: r.File, Time: r.Elapsed.Seconds(), SystemOut: r.Output}
+		if r.Failure != "" {
+			c.Failure = &junitFailure{Message: r.Failure, Text: fmt.Sprintf("%s: %s", r.location(), r.Failure)}
+			suite.Failures++
+			suites.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		suite.Time += c.Time
+		suites.Tests++
+		suites.Time += c.Time
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
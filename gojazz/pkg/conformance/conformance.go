@@ -0,0 +1,180 @@
+// Package conformance holds Jazz implementations to a shared suite of
+// scripts. Each script states what it must print with "// expect: ..."
+// comments, one per line of output, and may end in a runtime error stated
+// with "// expect runtime error: ...".
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+const (
+	expectOutput       = "// expect: "
+	expectRuntimeError = "// expect runtime error: "
+)
+
+// Expect is an expectation and the line it is written on.
+type Expect struct {
+	Line int
+	Text string
+}
+
+// Case is a script of the suite.
+type Case struct {
+	File         string
+	Source       string
+	Output       []Expect
+	RuntimeError *Expect // a message the error must contain, ignoring case and trailing punctuation, if the script must fail
+}
+
+// Load reads a case from file.
+func Load(file string) (*Case, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(file, string(b))
+}
+
+// Parse reads the expectations of a case from its source.
+func Parse(file, source string) (*Case, error) {
+	c := &Case{File: file, Source: source}
+	for n, line := range strings.Split(source, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if _, text, ok := strings.Cut(line, expectOutput); ok {
+			if c.RuntimeError != nil {
+				return nil, fmt.Errorf("%s:%d: output expected after the runtime error", file, n+1)
+			}
+			c.Output = append(c.Output, Expect{Line: n + 1, Text: text})
+		}
+		if _, text, ok := strings.Cut(line, expectRuntimeError); ok {
+			if c.RuntimeError != nil {
+				return nil, fmt.Errorf("%s:%d: more than one runtime error expected", file, n+1)
+			}
+			c.RuntimeError = &Expect{Line: n + 1, Text: text}
+		}
+	}
+	return c, nil
+}
+
+// ExitCode is the status the implementation must exit with, following the
+// sysexits convention.
+func (c *Case) ExitCode() int {
+	if c.RuntimeError != nil {
+		return 70
+	}
+	return 0
+}
+
+// Result is what an implementation did with a case.
+type Result struct {
+	Stdout string
+	Stderr string
+	Code   int
+}
+
+// Runner runs cases with an implementation.
+type Runner interface {
+	Run(c *Case) (*Result, error)
+}
+
+// Interpreter runs cases with the Go interpreter, in process.
+type Interpreter struct {
+	Options []jazz.InterpreterOpt
+}
+
+func (r *Interpreter) Run(c *Case) (*Result, error) {
+	var stdout, stderr bytes.Buffer
+	options := append(append([]jazz.InterpreterOpt{}, r.Options...), jazz.WithStdout(&stdout), jazz.WithStderr(&stderr))
+	interpreter := jazz.NewInterpreter(options...)
+
+	code := jazz.ReportError(&stderr, jazz.Run(interpreter, c.Source))
+	return &Result{Stdout: stdout.String(), Stderr: stderr.String(), Code: code}, nil
+}
+
+// Command runs cases with an external program, such as the cjazz build,
+// passing the path of the case after Args.
+type Command struct {
+	Args []string
+}
+
+func (r *Command) Run(c *Case) (*Result, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(r.Args[0], append(r.Args[1:], c.File)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	return &Result{Stdout: stdout.String(), Stderr: stderr.String(), Code: cmd.ProcessState.ExitCode()}, nil
+}
+
+// Mismatch is a way in which a result differs from its case. Line is the
+// expectation it breaks, or 0 if there is none.
+type Mismatch struct {
+	Line    int
+	Message string
+}
+
+func (m Mismatch) String() string {
+	if m.Line == 0 {
+		return m.Message
+	}
+	return fmt.Sprintf("line %d: %s", m.Line, m.Message)
+}
+
+// Check compares a result with what its case expects.
+func Check(c *Case, r *Result) []Mismatch {
+	mismatches := []Mismatch{}
+
+	output := strings.Split(strings.TrimSuffix(r.Stdout, "\n"), "\n")
+	if r.Stdout == "" {
+		output = nil
+	}
+	for n, expect := range c.Output {
+		switch {
+		case n >= len(output):
+			mismatches = append(mismatches, Mismatch{Line: expect.Line, Message: fmt.Sprintf("missing output %q", expect.Text)})
+		case output[n] != expect.Text:
+			mismatches = append(mismatches, Mismatch{Line: expect.Line, Message: fmt.Sprintf("expected %q, got %q", expect.Text, output[n])})
+		}
+	}
+	for _, line := range output[min(len(c.Output), len(output)):] {
+		mismatches = append(mismatches, Mismatch{Message: fmt.Sprintf("unexpected output %q", line)})
+	}
+
+	stderr := strings.TrimSpace(r.Stderr)
+	if c.RuntimeError != nil {
+		if r.Code != c.ExitCode() {
+			mismatches = append(mismatches, Mismatch{Line: c.RuntimeError.Line, Message: fmt.Sprintf("expected runtime error %q, got exit code %d: %s", c.RuntimeError.Text, r.Code, stderr)})
+		} else if !strings.Contains(normalize(r.Stderr), normalize(c.RuntimeError.Text)) {
+			mismatches = append(mismatches, Mismatch{Line: c.RuntimeError.Line, Message: fmt.Sprintf("expected runtime error %q, got %q", c.RuntimeError.Text, stderr)})
+		}
+	} else if r.Code != c.ExitCode() {
+		mismatches = append(mismatches, Mismatch{Message: fmt.Sprintf("unexpected exit code %d: %s", r.Code, stderr)})
+	}
+
+	return mismatches
+}
+
+// normalize folds case and drops trailing punctuation, so that implementations
+// may word the same error as "division by zero" or "Division by zero.".
+func normalize(msg string) string {
+	return strings.TrimRight(strings.ToLower(msg), ".!?: \n")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+package conformance
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestSuite holds the Go interpreter to the suite shared with cjazz.
+func TestSuite(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "conformance", "*.jz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no conformance scripts found")
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			c, err := Load(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := (&Interpreter{}).Run(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range Check(c, result) {
+				t.Errorf("%s: %s", file, m)
+			}
+		})
+	}
+}
+
+// TestCheckRuntimeErrorWording accepts the wording of both implementations,
+// which differ in case and trailing punctuation.
+func TestCheckRuntimeErrorWording(t *testing.T) {
+	c, err := Parse("case.jz", "print x; // expect runtime error: undefined variable 'x'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		stderr string
+		ok     bool
+	}{
+		{"undefined variable 'x'\n", true},
+		{"Runtime error: Undefined variable 'x'.\n[line 1] in script\n", true},
+		{"undefined variable 'y'\n", false},
+	}
+	for _, test := range tests {
+		mismatches := Check(c, &Result{Stderr: test.stderr, Code: 70})
+		if ok := len(mismatches) == 0; ok != test.ok {
+			t.Errorf("%q: got mismatches %v, want ok=%v", test.stderr, mismatches, test.ok)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package cover records which statements of Jazz programs run, as an
+// interpreter hook, and reports line and branch coverage as a summary, an
+// lcov tracefile or an annotated HTML page.
+package cover
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+// Coverage collects the coverage of several files. A file run more than once,
+// for instance once per test, adds up.
+type Coverage struct {
+	Files []*File
+}
+
+func New() *Coverage {
+	return &Coverage{}
+}
+
+// File returns the coverage of the named file, adding it on first use.
+func (c *Coverage) File(name, source string) *File {
+	for _, f := range c.Files {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	f := &File{Name: name, Source: source, hits: map[jazz.Stmt]int{}, lines: map[int][]jazz.Stmt{}}
+	c.Files = append(c.Files, f)
+	return f
+}
+
+// Branch is a way through an if, a loop or a match: the then or else of an
+// if, the body of a loop or an arm of a match. Taken is how many times it was
+// taken, or -1 if the statement it belongs to never ran.
+type Branch struct {
+	Line  int
+	Block int // the statement the branch belongs to, numbered within the file
+	Index int // the branch within its statement
+	Taken int
+}
+
+// File is the coverage of a single source file. It is a jazz.Hook that counts
+// the statements registered with Add.
+type File struct {
+	Name   string
+	Source string
+
+	hits     map[jazz.Stmt]int
+	lines    map[int][]jazz.Stmt // statements starting on each line
+	branches []branch
+}
+
+// branch describes how to count a Branch: taken is nil for the implicit else
+// of an if, which is taken whenever the if runs and its then does not.
+type branch struct {
+	stmt  jazz.Stmt
+	taken jazz.Stmt
+	then  jazz.Stmt
+	block int
+	index int
+}
+
+// Add registers the statements of a parsed program. Only statements added
+// are counted, so Add must see the same tree the interpreter runs.
+func (f *File) Add(stmts []jazz.Stmt) {
+	for _, stmt := range stmts {
+		f.add(stmt)
+	}
+}
+
+func (f *File) add(stmt jazz.Stmt) {
+	if _, ok := f.hits[stmt]; ok {
+		return
+	}
+	f.hits[stmt] = 0
+	if _, block := stmt.(*jazz.BlockStmt); !block {
+		line := jazz.StmtLine(stmt)
+		f.lines[line] = append(f.lines[line], stmt)
+	}
+
+	block := len(f.branches)
+	switch s := stmt.(type) {
+	case *jazz.BlockStmt:
+		f.Add(s.Stmts)
+	case *jazz.ForInStmt:
+		f.branches = append(f.branches, branch{stmt: s, taken: s.Body, block: block})
+		f.add(s.Body)
+	case *jazz.FuncStmt:
+		f.Add(s.Body)
+	case *jazz.IfStmt:
+		f.branches = append(f.branches,
+			branch{stmt: s, taken: s.ThenStmt, block: block},
+			branch{stmt: s, taken: s.ElseStmt, then: s.ThenStmt, block: block, index: 1})
+		f.add(s.ThenStmt)
+		if s.ElseStmt != nil {
+			f.add(s.ElseStmt)
+		}
+	case *jazz.MatchStmt:
+		for n, arm := range s.Arms {
+			f.branches = append(f.branches, branch{stmt: s, taken: arm.Body, block: block, index: n})
+			f.add(arm.Body)
+		}
+	case *jazz.WhileStmt:
+		f.branches = append(f.branches, branch{stmt: s, taken: s.Body, block: block})
+		f.add(s.Body)
+	}
+}
+
+// Before implements jazz.Hook.
+func (f *File) Before(_ *jazz.Interpreter, stmt jazz.Stmt) error {
+	if n, ok := f.hits[stmt]; ok {
+		f.hits[stmt] = n + 1
+	}
+	return nil
+}
+
+// Lines returns how many times each line holding a statement ran: the most
+// any statement starting on it ran.
+func (f *File) Lines() map[int]int {
+	lines := map[int]int{}
+	for line, stmts := range f.lines {
+		for _, stmt := range stmts {
+			if f.hits[stmt] > lines[line] {
+				lines[line] = f.hits[stmt]
+			}
+		}
+		if _, ok := lines[line]; !ok {
+			lines[line] = 0
+		}
+	}
+	return lines
+}
+
+// Branches returns every branch in source order.
+func (f *File) Branches() []Branch {
+	branches := make([]Branch, 0, len(f.branches))
+	for _, b := range f.branches {
+		runs := f.hits[b.stmt]
+		taken := -1
+		switch {
+		case runs == 0:
+		case b.taken != nil:
+			taken = f.hits[b.taken]
+		default:
+			taken = runs - f.hits[b.then]
+		}
+		branches = append(branches, Branch{Line: jazz.StmtLine(b.stmt), Block: b.block, Index: b.index, Taken: taken})
+	}
+	sort.SliceStable(branches, func(i, j int) bool {
+		return branches[i].Line < branches[j].Line
+	})
+	return branches
+}
+
+// Summary is how much of a file, or of several, ran.
+type Summary struct {
+	Lines, LinesHit       int
+	Branches, BranchesHit int
+}
+
+func (f *File) Summary() Summary {
+	var s Summary
+	for _, hits := range f.Lines() {
+		s.Lines++
+		if hits > 0 {
+			s.LinesHit++
+		}
+	}
+	for _, b := range f.Branches() {
+		s.Branches++
+		if b.Taken > 0 {
+			s.BranchesHit++
+		}
+	}
+	return s
+}
+
+func (s Summary) add(other Summary) Summary {
+	return Summary{
+		Lines:       s.Lines + other.Lines,
+		LinesHit:    s.LinesHit + other.LinesHit,
+		Branches:    s.Branches + other.Branches,
+		BranchesHit: s.BranchesHit + other.BranchesHit,
+	}
+}
+
+func percent(hit, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(hit) / float64(total)
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%.1f%% of lines (%d/%d), %.1f%% of branches (%d/%d)",
+		percent(s.LinesHit, s.Lines), s.LinesHit, s.Lines,
+		percent(s.BranchesHit, s.Branches), s.BranchesHit, s.Branches)
+}
+
+// WriteSummary writes the coverage of each file and, for several files, the
+// total.
+func (c *Coverage) WriteSummary(w io.Writer) error {
+	var total Summary
+	for _, f := range c.Files {
+		s := f.Summary()
+		total = total.add(s)
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.Name, s); err != nil {
+			return err
+		}
+	}
+	if len(c.Files) > 1 {
+		_, err := fmt.Fprintf(w, "total: %s\n", total)
+		return err
+	}
+	return nil
+}
+
+// WriteLCOV writes an lcov tracefile, as read by genhtml and most coverage
+// services.
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	for _, f := range c.Files {
+		if _, err := fmt.Fprintf(w, "TN:\nSF:%s\n", f.Name); err != nil {
+			return err
+		}
+
+		s := f.Summary()
+		for _, b := range f.Branches() {
+			taken := "-"
+			if b.Taken >= 0 {
+				taken = fmt.Sprint(b.Taken)
+			}
+			if _, err := fmt.Fprintf(w, "BRDA:%d,%d,%d,%s\n", b.Line, b.Block, b.Index, taken); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "BRF:%d\nBRH:%d\n", s.Branches, s.BranchesHit); err != nil {
+			return err
+		}
+
+		lines := f.Lines()
+		for _, line := range sortedLines(lines) {
+			if _, err := fmt.Fprintf(w, "DA:%d,%d\n", line, lines[line]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "LF:%d\nLH:%d\nend_of_record\n", s.Lines, s.LinesHit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedLines(lines map[int]int) []int {
+	sorted := make([]int, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
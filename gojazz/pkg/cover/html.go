@@ -0,0 +1,97 @@
+package cover
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("cover").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Jazz coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.summary td, table.summary th { padding: 0.2em 1em; text-align: left; }
+pre { font-family: monospace; line-height: 1.3; margin: 0; }
+.source { border-collapse: collapse; margin-bottom: 2em; }
+.source td { padding: 0 0.5em; vertical-align: top; }
+.num, .hits { color: #888; text-align: right; user-select: none; }
+.hit { background: #dfd; }
+.miss { background: #fdd; }
+.partial { background: #ffc; }
+</style>
+</head>
+<body>
+<h1>Jazz coverage</h1>
+<table class="summary">
+<tr><th>File</th><th>Lines</th><th>Branches</th></tr>
+{{range .}}<tr><td><a href="#{{.Anchor}}">{{.Name}}</a></td><td>{{.Lines}}</td><td>{{.Branches}}</td></tr>
+{{end}}</table>
+{{range .}}<h2 id="{{.Anchor}}">{{.Name}}</h2>
+<table class="source">
+{{range .Rows}}<tr class="{{.Class}}"><td class="num">{{.Num}}</td><td class="hits">{{.Hits}}</td><td><pre>{{.Text}}</pre></td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+type htmlFile struct {
+	Name     string
+	Anchor   string
+	Lines    string
+	Branches string
+	Rows     []htmlRow
+}
+
+type htmlRow struct {
+	Num   int
+	Hits  string
+	Class string // hit, miss or partial, if the line holds a statement
+	Text  string
+}
+
+// WriteHTML writes a page showing the source of every file, with the lines
+// that ran in green, those that did not in red and those with a branch never
+// taken in yellow.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	files := make([]htmlFile, 0, len(c.Files))
+	for n, f := range c.Files {
+		s := f.Summary()
+		file := htmlFile{
+			Name:     f.Name,
+			Anchor:   fmt.Sprintf("file%d", n),
+			Lines:    fmt.Sprintf("%.1f%% (%d/%d)", percent(s.LinesHit, s.Lines), s.LinesHit, s.Lines),
+			Branches: fmt.Sprintf("%.1f%% (%d/%d)", percent(s.BranchesHit, s.Branches), s.BranchesHit, s.Branches),
+		}
+
+		partial := map[int]bool{}
+		for _, b := range f.Branches() {
+			if b.Taken == 0 {
+				partial[b.Line] = true
+			}
+		}
+
+		lines := f.Lines()
+		for n, text := range strings.Split(f.Source, "\n") {
+			row := htmlRow{Num: n + 1, Text: strings.TrimRight(text, "\r")}
+			if hits, ok := lines[n+1]; ok {
+				row.Hits = fmt.Sprint(hits)
+				switch {
+				case hits == 0:
+					row.Class = "miss"
+				case partial[n+1]:
+					row.Class = "partial"
+				default:
+					row.Class = "hit"
+				}
+			}
+			file.Rows = append(file.Rows, row)
+		}
+		files = append(files, file)
+	}
+
+	return htmlTemplate.Execute(w, files)
+}
@@ -0,0 +1,524 @@
+// Package dap implements a Debug Adapter Protocol server for Jazz over a pair
+// of streams, usually stdin and stdout.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/debug"
+	"github.com/thepatrik/jazz/gojazz/pkg/internal/wire"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+// threadID is the only thread a Jazz program has.
+const threadID = 1
+
+type Server struct {
+	in      *bufio.Reader
+	out     io.Writer
+	options []jazz.InterpreterOpt
+
+	writeMu sync.Mutex // guards out and seq
+	seq     int
+
+	program     string
+	stmts       []jazz.Stmt
+	lines       map[int]bool // lines holding a statement
+	interpreter *jazz.Interpreter
+	dbg         *debug.Debugger
+	breakpoints map[string][]int // by absolute source path
+	done        chan struct{}    // closed when the program has ended
+
+	mu     sync.Mutex // guards stop and refs, set by the program goroutine
+	stop   *debug.Stop
+	refs   []interface{} // variablesReference n is refs[n-1]
+	resume chan debug.Action
+
+	then func() // run after the current response is written
+}
+
+// NewServer returns a server reading requests from in and writing responses
+// and events to out. Programs are run with options.
+func NewServer(in io.Reader, out io.Writer, options ...jazz.InterpreterOpt) *Server {
+	return &Server{
+		in:          bufio.NewReader(in),
+		out:         out,
+		options:     options,
+		breakpoints: map[string][]int{},
+		resume:      make(chan debug.Action),
+	}
+}
+
+// Run serves requests until the client disconnects or closes the input.
+func (s *Server) Run() error {
+	defer s.shutdown()
+
+	for {
+		body, err := wire.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("dap: invalid message: %w", err)
+		}
+
+		result, err := s.handle(&req)
+		if err := s.reply(&req, result, err); err != nil {
+			return err
+		}
+		if s.then != nil {
+			then := s.then
+			s.then = nil
+			then()
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// shutdown stops a program still running and waits for it to end.
+func (s *Server) shutdown() {
+	if s.done == nil {
+		return
+	}
+	s.dbg.Terminate()
+	for {
+		select {
+		case s.resume <- debug.Terminate:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// read reads the body of the next message.
+// write sends a message, numbering it with the next sequence number.
+func (s *Server) write(msg interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	switch m := msg.(type) {
+	case *response:
+		m.Seq = s.seq
+	case *event:
+		m.Seq = s.seq
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return wire.Write(s.out, body)
+}
+
+func (s *Server) reply(req *request, body interface{}, err error) error {
+	res := &response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+	if err != nil {
+		res.Message = err.Error()
+	}
+	return s.write(res)
+}
+
+func (s *Server) event(name string, body interface{}) {
+	// A client that has gone away is noticed by Run on its next read.
+	_ = s.write(&event{Type: "event", Event: name, Body: body})
+}
+
+func (s *Server) handle(req *request) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		s.then = func() { s.event("initialized", nil) }
+		return &Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		}, nil
+	case "launch":
+		var args launchArguments
+		if err := unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(args), nil
+	case "configurationDone":
+		if s.interpreter == nil {
+			return nil, errors.New("no program launched")
+		}
+		s.then = s.start
+		return nil, nil
+	case "threads":
+		return map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}}, nil
+	case "stackTrace":
+		var args stackTraceArguments
+		if err := unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.stackTrace(args)
+	case "scopes":
+		var args scopesArguments
+		if err := unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args)
+	case "variables":
+		var args variablesArguments
+		if err := unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args)
+	case "evaluate":
+		var args evaluateArguments
+		if err := unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.evaluate(args)
+	case "continue":
+		return map[string]interface{}{"allThreadsContinued": true}, s.continueWith(debug.Continue)
+	case "next":
+		return nil, s.continueWith(debug.StepOver)
+	case "stepIn":
+		return nil, s.continueWith(debug.StepIn)
+	case "stepOut":
+		return nil, s.continueWith(debug.StepOut)
+	case "terminate":
+		s.then = s.shutdown
+		return nil, nil
+	case "disconnect":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported command: %s", req.Command)
+}
+
+func unmarshal(args json.RawMessage, v interface{}) error {
+	if len(args) == 0 {
+		return nil
+	}
+	return json.Unmarshal(args, v)
+}
+
+// launch loads, parses and resolves the program. It starts running once the
+// client is done configuring it.
+func (s *Server) launch(args launchArguments) error {
+	if s.interpreter != nil {
+		return errors.New("a program is already launched")
+	}
+
+	program, err := filepath.Abs(args.Program)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(program)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := jazz.NewScanner(string(b)).ScanTokens()
+	if err != nil {
+		return err
+	}
+	stmts, err := jazz.NewParser(tokens, jazz.WithErrOutput(io.Discard), jazz.WithRecovery(true)).Parse()
+	if err != nil {
+		return err
+	}
+
+	s.dbg = debug.New(s.pause)
+	s.dbg.StopOnEntry = args.StopOnEntry
+	options := append(append([]jazz.InterpreterOpt{}, s.options...),
+		jazz.WithArgs(args.Args),
+		// The adapter may be talking the protocol on stdin, so scripts read
+		// end of input.
+		jazz.WithStdin(strings.NewReader("")),
+		jazz.WithStdout(&output{s: s, category: "stdout"}),
+		jazz.WithStderr(&output{s: s, category: "stderr"}))
+	if !args.NoDebug {
+		options = append(options, jazz.WithHook(s.dbg))
+	}
+	interpreter := jazz.NewInterpreter(options...)
+
+	resolver := jazz.NewResolver(interpreter)
+	if err := resolver.Resolve(stmts); err != nil {
+		return err
+	}
+	for _, warning := range resolver.Warnings {
+		s.event("output", &OutputEvent{Category: "console", Output: fmt.Sprintf("warning: %s\n", warning)})
+	}
+
+	s.program, s.stmts, s.interpreter = program, stmts, interpreter
+	s.lines = map[int]bool{}
+	statementLines(stmts, s.lines)
+	s.dbg.SetBreakpoints(s.breakpoints[program]...)
+	return nil
+}
+
+// start runs the program on its own goroutine, so that requests are served
+// while it runs or is paused.
+func (s *Server) start() {
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+
+		code := 0
+		err := s.interpreter.Interpret(s.stmts)
+		var exitErr *jazz.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			code = exitErr.Code
+		case errors.Is(err, debug.ErrTerminated):
+		case err != nil:
+			s.event("output", &OutputEvent{Category: "stderr", Output: err.Error() + "\n"})
+			code = 70
+		}
+
+		s.event("exited", &ExitedEvent{ExitCode: code})
+		s.event("terminated", nil)
+	}()
+}
+
+// pause is called on the program goroutine when it stops, and blocks until a
+// request resumes it.
+func (s *Server) pause(stop *debug.Stop) debug.Action {
+	s.mu.Lock()
+	s.stop, s.refs = stop, nil
+	s.mu.Unlock()
+
+	reason, description := stop.Reason, ""
+	if stop.Reason == debug.ReasonDebugger {
+		reason, description = "pause", "Paused on debugger statement"
+	}
+	s.event("stopped", &StoppedEvent{Reason: reason, Description: description, ThreadID: threadID, AllThreadsStopped: true})
+
+	return <-s.resume
+}
+
+// paused returns where the program is paused.
+func (s *Server) paused() (*debug.Stop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return nil, errors.New("the program is not paused")
+	}
+	return s.stop, nil
+}
+
+// continueWith resumes a paused program once the response has been sent.
+func (s *Server) continueWith(action debug.Action) error {
+	if _, err := s.paused(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.stop, s.refs = nil, nil
+	s.mu.Unlock()
+
+	s.then = func() { s.resume <- action }
+	return nil
+}
+
+func (s *Server) setBreakpoints(args setBreakpointsArguments) interface{} {
+	path, err := filepath.Abs(args.Source.Path)
+	if err != nil {
+		path = args.Source.Path
+	}
+
+	lines := []int{}
+	breakpoints := []Breakpoint{}
+	for _, bp := range args.Breakpoints {
+		lines = append(lines, bp.Line)
+		breakpoint := Breakpoint{Verified: true, Line: bp.Line}
+		if path == s.program && !s.lines[bp.Line] {
+			breakpoint.Verified, breakpoint.Message = false, "no statement on this line"
+		}
+		breakpoints = append(breakpoints, breakpoint)
+	}
+
+	s.breakpoints[path] = lines
+	if s.dbg != nil && path == s.program {
+		s.dbg.SetBreakpoints(lines...)
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}
+}
+
+// Frame IDs count from 1 for the innermost frame.
+func (s *Server) stackTrace(args stackTraceArguments) (interface{}, error) {
+	stop, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+
+	frames := stop.Frames()
+	start, end := args.StartFrame, len(frames)
+	if start > end {
+		start = end
+	}
+	if args.Levels > 0 && start+args.Levels < end {
+		end = start + args.Levels
+	}
+
+	source := &Source{Name: filepath.Base(s.program), Path: s.program}
+	stackFrames := []StackFrame{}
+	for n := start; n < end; n++ {
+		stackFrames = append(stackFrames, StackFrame{ID: n + 1, Name: frames[n].Name(), Source: source, Line: frames[n].Line, Column: 1})
+	}
+	return map[string]interface{}{"stackFrames": stackFrames, "totalFrames": len(frames)}, nil
+}
+
+func (s *Server) frame(stop *debug.Stop, id int) (*jazz.Frame, error) {
+	frames := stop.Frames()
+	if id == 0 {
+		id = 1
+	}
+	if id < 1 || id > len(frames) {
+		return nil, fmt.Errorf("no frame %d", id)
+	}
+	return frames[id-1], nil
+}
+
+func (s *Server) scopes(args scopesArguments) (interface{}, error) {
+	stop, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+	frame, err := s.frame(stop, args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []Scope{
+		{Name: "Locals", VariablesReference: s.ref(debug.Locals(stop.Interpreter, frame))},
+		{Name: "Globals", VariablesReference: s.ref(debug.Globals(stop.Interpreter))},
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+// ref returns a variablesReference for a scope's variables, an array or a
+// map. References are valid until the program resumes.
+func (s *Server) ref(val interface{}) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refs = append(s.refs, val)
+	return len(s.refs)
+}
+
+func (s *Server) variables(args variablesArguments) (interface{}, error) {
+	if _, err := s.paused(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	n := args.VariablesReference
+	if n < 1 || n > len(s.refs) {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("no variables with reference %d", n)
+	}
+	val := s.refs[n-1]
+	s.mu.Unlock()
+
+	vars := []Variable{}
+	switch v := val.(type) {
+	case []debug.Variable:
+		for _, variable := range v {
+			vars = append(vars, s.variable(variable.Name, variable.Value))
+		}
+	case *jazz.JazzArray:
+		for n, el := range v.Elements {
+			vars = append(vars, s.variable(strconv.Itoa(n), el))
+		}
+	case *jazz.JazzMap:
+		for _, key := range v.Keys() {
+			el, _ := v.Get(key)
+			vars = append(vars, s.variable(key, el))
+		}
+	}
+	return map[string]interface{}{"variables": vars}, nil
+}
+
+// variable describes a value, giving arrays and maps a reference so that the
+// client can expand them.
+func (s *Server) variable(name string, val interface{}) Variable {
+	v := Variable{Name: name, Value: jazz.Repr(val)}
+	switch val.(type) {
+	case *jazz.JazzArray, *jazz.JazzMap:
+		v.VariablesReference = s.ref(val)
+	}
+	return v
+}
+
+func (s *Server) evaluate(args evaluateArguments) (interface{}, error) {
+	stop, err := s.paused()
+	if err != nil {
+		return nil, err
+	}
+	frame, err := s.frame(stop, args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := stop.Interpreter.Evaluate(args.Expression, frame.Env)
+	if err != nil {
+		return nil, err
+	}
+	v := s.variable("", val)
+	return map[string]interface{}{"result": v.Value, "variablesReference": v.VariablesReference}, nil
+}
+
+// output sends what a program writes to the client as output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.s.event("output", &OutputEvent{Category: o.category, Output: string(p)})
+	return len(p), nil
+}
+
+// statementLines records the lines holding a statement, where breakpoints can
+// be hit.
+func statementLines(stmts []jazz.Stmt, lines map[int]bool) {
+	for _, stmt := range stmts {
+		lines[jazz.StmtLine(stmt)] = true
+		switch s := stmt.(type) {
+		case *jazz.BlockStmt:
+			statementLines(s.Stmts, lines)
+		case *jazz.ForInStmt:
+			statementLines([]jazz.Stmt{s.Body}, lines)
+		case *jazz.FuncStmt:
+			statementLines(s.Body, lines)
+		case *jazz.IfStmt:
+			statementLines([]jazz.Stmt{s.ThenStmt}, lines)
+			if s.ElseStmt != nil {
+				statementLines([]jazz.Stmt{s.ElseStmt}, lines)
+			}
+		case *jazz.MatchStmt:
+			for _, arm := range s.Arms {
+				statementLines([]jazz.Stmt{arm.Body}, lines)
+			}
+		case *jazz.WhileStmt:
+			statementLines([]jazz.Stmt{s.Body}, lines)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const program = `fn makeCounter() {
+    let i = 0;
+    fn count() {
+        i = i + 1;
+        return i;
+    }
+    return count;
+}
+
+let counter = makeCounter();
+let seen = [counter(), {"n": counter()}];
+debugger;
+print seen;
+`
+
+// message is any message from the server.
+type message struct {
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client scripts a debug session against a server.
+type client struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	msgs   chan *message
+	seq    int
+	events []*message // received while waiting for responses
+}
+
+func newClient(t *testing.T) *client {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	server := NewServer(serverIn, serverOut)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run()
+		serverOut.Close()
+	}()
+
+	c := &client{t: t, w: clientOut, r: bufio.NewReader(clientIn), msgs: make(chan *message, 100)}
+	go c.readAll()
+	t.Cleanup(func() {
+		clientOut.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	})
+	return c
+}
+
+func (c *client) readAll() {
+	defer close(c.msgs)
+	for {
+		header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(c.r, body); err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			c.t.Errorf("invalid message %s: %v", body, err)
+			return
+		}
+		c.msgs <- &msg
+	}
+}
+
+func (c *client) next() *message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.msgs:
+		if !ok {
+			c.t.Fatal("server closed the connection")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+	return nil
+}
+
+// request sends a request and returns its response, decoding the body into
+// body if it is non-nil.
+func (c *client) request(command string, args interface{}, body interface{}) *message {
+	c.t.Helper()
+	c.seq++
+	b, _ := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+
+	for {
+		msg := c.next()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg.RequestSeq != c.seq || msg.Command != command {
+			c.t.Fatalf("got response to %s #%d, want %s #%d", msg.Command, msg.RequestSeq, command, c.seq)
+		}
+		if body != nil && msg.Success {
+			if err := json.Unmarshal(msg.Body, body); err != nil {
+				c.t.Fatalf("%s: invalid body %s: %v", command, msg.Body, err)
+			}
+		}
+		return msg
+	}
+}
+
+func (c *client) mustRequest(command string, args interface{}, body interface{}) {
+	c.t.Helper()
+	if msg := c.request(command, args, body); !msg.Success {
+		c.t.Fatalf("%s failed: %s", command, msg.Message)
+	}
+}
+
+// waitEvent returns the next event named name, decoding its body into body.
+func (c *client) waitEvent(name string, body interface{}) {
+	c.t.Helper()
+	for {
+		var msg *message
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Type != "event" {
+			c.t.Fatalf("got unexpected %s to %s", msg.Type, msg.Command)
+		}
+		if msg.Event == name {
+			if body != nil {
+				if err := json.Unmarshal(msg.Body, body); err != nil {
+					c.t.Fatalf("%s: invalid body %s: %v", name, msg.Body, err)
+				}
+			}
+			return
+		}
+		if msg.Event == "output" && name != "output" {
+			continue
+		}
+		if msg.Event != "output" && msg.Event != "initialized" {
+			c.t.Fatalf("got %s event, want %s", msg.Event, name)
+		}
+	}
+}
+
+// stoppedAt waits for the program to stop and checks why and where.
+func (c *client) stoppedAt(reason string, line int, frame string) []StackFrame {
+	c.t.Helper()
+	var stopped StoppedEvent
+	c.waitEvent("stopped", &stopped)
+	if stopped.Reason != reason {
+		c.t.Errorf("stopped for %q, want %q", stopped.Reason, reason)
+	}
+
+	var trace struct{ StackFrames []StackFrame }
+	c.mustRequest("stackTrace", map[string]interface{}{"threadId": threadID}, &trace)
+	if len(trace.StackFrames) == 0 {
+		c.t.Fatal("no stack frames")
+	}
+	if top := trace.StackFrames[0]; top.Line != line || top.Name != frame {
+		c.t.Errorf("stopped in %s at line %d, want %s at line %d", top.Name, top.Line, frame, line)
+	}
+	return trace.StackFrames
+}
+
+func (c *client) variables(ref int) map[string]Variable {
+	c.t.Helper()
+	var body struct{ Variables []Variable }
+	c.mustRequest("variables", map[string]interface{}{"variablesReference": ref}, &body)
+
+	vars := map[string]Variable{}
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func (c *client) scopes(frameID int) (locals, globals int) {
+	c.t.Helper()
+	var body struct{ Scopes []Scope }
+	c.mustRequest("scopes", map[string]interface{}{"frameId": frameID}, &body)
+	if len(body.Scopes) != 2 {
+		c.t.Fatalf("got %d scopes, want 2", len(body.Scopes))
+	}
+	return body.Scopes[0].VariablesReference, body.Scopes[1].VariablesReference
+}
+
+func writeProgram(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "counter.jz")
+	if err := os.WriteFile(path, []byte(program), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func (c *client) launch(path string, stopOnEntry bool, lines ...int) {
+	c.t.Helper()
+	c.mustRequest("initialize", map[string]interface{}{"adapterID": "jazz", "linesStartAt1": true}, nil)
+	c.waitEvent("initialized", nil)
+	c.mustRequest("launch", map[string]interface{}{"program": path, "stopOnEntry": stopOnEntry}, nil)
+
+	breakpoints := []map[string]int{}
+	for _, line := range lines {
+		breakpoints = append(breakpoints, map[string]int{"line": line})
+	}
+	var body struct{ Breakpoints []Breakpoint }
+	c.mustRequest("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": path}, "breakpoints": breakpoints}, &body)
+	for _, bp := range body.Breakpoints {
+		if !bp.Verified {
+			c.t.Errorf("breakpoint on line %d not verified: %s", bp.Line, bp.Message)
+		}
+	}
+	c.mustRequest("configurationDone", nil, nil)
+}
+
+func TestBreakpointsAndInspection(t *testing.T) {
+	c := newClient(t)
+	c.launch(writeProgram(t), false, 4)
+
+	var threads struct{ Threads []Thread }
+	c.mustRequest("threads", nil, &threads)
+	if len(threads.Threads) != 1 {
+		t.Errorf("got %d threads, want 1", len(threads.Threads))
+	}
+
+	frames := c.stoppedAt("breakpoint", 4, "count")
+	if len(frames) != 2 || frames[1].Name != "<script>" || frames[1].Line != 11 {
+		t.Errorf("got frames %+v, want count called from <script> at line 11", frames)
+	}
+
+	locals, globals := c.scopes(frames[0].ID)
+	if v := c.variables(locals)["i"]; v.Value != "0" {
+		t.Errorf("got i = %q, want 0", v.Value)
+	}
+	if _, ok := c.variables(globals)["counter"]; !ok {
+		t.Error("counter missing from globals")
+	}
+	if _, ok := c.variables(globals)["clock"]; ok {
+		t.Error("natives listed in globals")
+	}
+
+	var result struct{ Result string }
+	c.mustRequest("evaluate", map[string]interface{}{"expression": "i + 41", "frameId": frames[0].ID}, &result)
+	if result.Result != "41" {
+		t.Errorf("evaluate i + 41 = %q, want 41", result.Result)
+	}
+	if msg := c.request("evaluate", map[string]interface{}{"expression": "nope", "frameId": frames[0].ID}, nil); msg.Success {
+		t.Error("evaluating an undefined variable succeeded")
+	}
+
+	// Clear the breakpoint and run to the debugger statement.
+	c.mustRequest("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": frames[0].Source.Path}, "breakpoints": []int{}}, nil)
+	c.mustRequest("continue", map[string]interface{}{"threadId": threadID}, nil)
+	frames = c.stoppedAt("pause", 12, "<script>")
+
+	_, globals = c.scopes(frames[0].ID)
+	seen := c.variables(globals)["seen"]
+	if seen.VariablesReference == 0 {
+		t.Fatal("array is not expandable")
+	}
+	elements := c.variables(seen.VariablesReference)
+	if elements["0"].Value != "1" {
+		t.Errorf("got seen[0] = %q, want 1", elements["0"].Value)
+	}
+	if entries := c.variables(elements["1"].VariablesReference); entries["n"].Value != "2" {
+		t.Errorf("got seen[1].n = %q, want 2", entries["n"].Value)
+	}
+
+	c.mustRequest("continue", map[string]interface{}{"threadId": threadID}, nil)
+	var output OutputEvent
+	c.waitEvent("output", &output)
+	if output.Category != "stdout" || output.Output != "[1, {n: 2}]\n" {
+		t.Errorf("got %s output %q", output.Category, output.Output)
+	}
+	var exited ExitedEvent
+	c.waitEvent("exited", &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("exited with %d, want 0", exited.ExitCode)
+	}
+	c.waitEvent("terminated", nil)
+	c.mustRequest("disconnect", nil, nil)
+}
+
+func TestStepping(t *testing.T) {
+	c := newClient(t)
+	c.launch(writeProgram(t), true)
+
+	c.stoppedAt("entry", 1, "<script>")
+	c.mustRequest("next", map[string]interface{}{"threadId": threadID}, nil)
+	c.stoppedAt("step", 10, "<script>")
+	c.mustRequest("stepIn", map[string]interface{}{"threadId": threadID}, nil)
+	c.stoppedAt("step", 2, "makeCounter")
+	c.mustRequest("next", map[string]interface{}{"threadId": threadID}, nil)
+	c.stoppedAt("step", 3, "makeCounter")
+	c.mustRequest("stepOut", map[string]interface{}{"threadId": threadID}, nil)
+	c.stoppedAt("step", 11, "<script>")
+	c.mustRequest("next", map[string]interface{}{"threadId": threadID}, nil)
+	c.stoppedAt("pause", 12, "<script>")
+
+	if msg := c.request("stepIn", map[string]interface{}{"threadId": 7}, nil); !msg.Success {
+		t.Errorf("stepIn failed: %s", msg.Message)
+	}
+	c.stoppedAt("step", 13, "<script>")
+	c.mustRequest("disconnect", nil, nil)
+}
+
+func TestNotPaused(t *testing.T) {
+	c := newClient(t)
+	if msg := c.request("stackTrace", map[string]interface{}{"threadId": threadID}, nil); msg.Success {
+		t.Error("stackTrace succeeded without a program")
+	}
+	if msg := c.request("continue", map[string]interface{}{"threadId": threadID}, nil); msg.Success {
+		t.Error("continue succeeded without a program")
+	}
+	if msg := c.request("launch", map[string]interface{}{"program": filepath.Join(t.TempDir(), "missing.jz")}, nil); msg.Success {
+		t.Error("launching a missing program succeeded")
+	}
+	c.mustRequest("disconnect", nil, nil)
+}
@@ -0,0 +1,220 @@
+// Package debug implements breakpoints and stepping on top of the
+// interpreter's statement hook. Front ends, such as the terminal debugger and
+// the Debug Adapter Protocol server, decide what to do while paused.
+package debug
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+// ErrTerminated is returned from Interpret when the program is stopped from a
+// pause with Terminate.
+var ErrTerminated = errors.New("debug: terminated")
+
+// Action tells a paused program how to resume.
+type Action int
+
+const (
+	Continue  Action = iota // run to the next breakpoint
+	StepIn                  // stop at the next line, entering calls
+	StepOver                // stop at the next line in this frame or a caller
+	StepOut                 // stop once the current frame has returned
+	Terminate               // stop the program
+)
+
+// Stop reasons.
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonDebugger   = "debugger"
+)
+
+// Stop describes where a program paused.
+type Stop struct {
+	Reason      string
+	Line        int
+	Interpreter *jazz.Interpreter
+}
+
+// Frames returns the paused program's frames, innermost first.
+func (stop *Stop) Frames() []*jazz.Frame {
+	return stop.Interpreter.Frames()
+}
+
+// Debugger is a jazz.Hook that pauses at breakpoints, after steps and at
+// 'debugger;' statements. Pause is called on the interpreter's goroutine and
+// the program stays paused until it returns.
+type Debugger struct {
+	Pause       func(stop *Stop) Action
+	StopOnEntry bool
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	terminated  bool
+	started     bool
+	action      Action
+	from        int // call depth the last action was taken at
+	line        int // line of the last statement seen
+	depth       int // call depth of the last statement seen
+}
+
+func New(pause func(stop *Stop) Action) *Debugger {
+	return &Debugger{Pause: pause, breakpoints: map[int]bool{}}
+}
+
+// SetBreakpoints replaces the breakpoints with lines. It may be called while
+// the program runs.
+func (d *Debugger) SetBreakpoints(lines ...int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakpoints = map[int]bool{}
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+// AddBreakpoint sets a breakpoint on line.
+func (d *Debugger) AddBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// ClearBreakpoint removes the breakpoint on line, reporting whether there was
+// one.
+func (d *Debugger) ClearBreakpoint(line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ok := d.breakpoints[line]
+	delete(d.breakpoints, line)
+	return ok
+}
+
+// Terminate stops the program before its next statement. It may be called
+// while the program runs.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.terminated = true
+}
+
+// Breakpoints returns the lines with breakpoints, sorted.
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Before implements jazz.Hook. Breakpoints and steps only stop at the first
+// statement run on a line, so that a line holding nested statements is
+// stopped at once.
+func (d *Debugger) Before(i *jazz.Interpreter, stmt jazz.Stmt) error {
+	d.mu.Lock()
+	terminated := d.terminated
+	d.mu.Unlock()
+	if terminated {
+		return ErrTerminated
+	}
+
+	line, depth := jazz.StmtLine(stmt), i.CallDepth()
+	moved := line != d.line || depth != d.depth
+	d.line, d.depth = line, depth
+
+	reason := d.reason(stmt, line, depth, moved)
+	if reason == "" {
+		return nil
+	}
+
+	action := d.Pause(&Stop{Reason: reason, Line: line, Interpreter: i})
+	if action == Terminate {
+		return ErrTerminated
+	}
+	d.action, d.from = action, depth
+	return nil
+}
+
+func (d *Debugger) reason(stmt jazz.Stmt, line, depth int, moved bool) string {
+	if _, ok := stmt.(*jazz.DebuggerStmt); ok {
+		return ReasonDebugger
+	}
+	if !moved {
+		return ""
+	}
+	if !d.started {
+		d.started = true
+		if d.StopOnEntry {
+			return ReasonEntry
+		}
+	}
+
+	switch {
+	case d.action == StepIn,
+		d.action == StepOver && depth <= d.from,
+		d.action == StepOut && depth < d.from:
+		return ReasonStep
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.breakpoints[line] {
+		return ReasonBreakpoint
+	}
+	return ""
+}
+
+// Variable is a name visible in a frame.
+type Variable struct {
+	Name  string
+	Value interface{}
+}
+
+// Locals returns the variables visible in frame that are not globals,
+// innermost first, by walking the ancestors of its environment. Names shadowed
+// by an inner declaration are left out.
+func Locals(i *jazz.Interpreter, frame *jazz.Frame) []Variable {
+	return variables(frame.Env, i.Globals())
+}
+
+// Globals returns the global variables, leaving out natives.
+func Globals(i *jazz.Interpreter) []Variable {
+	vars := []Variable{}
+	for _, v := range variables(i.Globals(), nil) {
+		if _, native := v.Value.(jazz.Callable); native {
+			if _, fn := v.Value.(*jazz.Func); !fn {
+				continue
+			}
+		}
+		vars = append(vars, v)
+	}
+	return vars
+}
+
+// variables lists the names defined from env up to, but not including, stop.
+func variables(env, stop *jazz.Env) []Variable {
+	vars := []Variable{}
+	seen := map[string]bool{}
+	for ; env != nil && env != stop; env = env.Enclosing() {
+		for _, name := range env.Names() {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			val, _ := env.Lookup(name)
+			vars = append(vars, Variable{Name: name, Value: val})
+		}
+	}
+	return vars
+}
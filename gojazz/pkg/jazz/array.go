@@ -7,6 +7,7 @@ import (
 
 type JazzArray struct {
 	Elements []interface{}
+	Frozen   bool
 }
 
 func NewJazzArray(elements []interface{}) *JazzArray {
@@ -20,3 +21,23 @@ func (a *JazzArray) String() string {
 	}
 	return "[" + strings.Join(parts, ", ") + "]"
 }
+
+// Freeze makes the array, and every array or map nested in it, read-only.
+func (a *JazzArray) Freeze() {
+	if a.Frozen {
+		return
+	}
+	a.Frozen = true
+	for _, el := range a.Elements {
+		freeze(el)
+	}
+}
+
+func freeze(val interface{}) {
+	switch v := val.(type) {
+	case *JazzArray:
+		v.Freeze()
+	case *JazzMap:
+		v.Freeze()
+	}
+}
@@ -0,0 +1,152 @@
+package jazz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var testNatives = map[string]Callable{
+	"assert":        &Native{Name: "assert", Params: -1, Fn: assert},
+	"assert_eq":     &Native{Name: "assert_eq", Params: -1, Fn: assertEq},
+	"assert_throws": &Native{Name: "assert_throws", Params: -1, Fn: assertThrows},
+}
+
+// AssertionError is raised by a failing assert.
+type AssertionError struct {
+	Line    int
+	Message string
+}
+
+func (err *AssertionError) Error() string {
+	return fmt.Sprintf("[line %d] assertion failed: %s", err.Line, err.Message)
+}
+
+// fail raises an AssertionError at the running statement, prefixed by the
+// optional message argument after the first n.
+func fail(i *Interpreter, native string, args []interface{}, n int, msg string) {
+	if len(args) > n+1 {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s() expects at most %d arguments, got %d", native, n+1, len(args))})
+	}
+	if len(args) == n+1 {
+		msg = fmt.Sprintf("%s: %s", stringArg(native, args, n), msg)
+	}
+	panic(&AssertionError{Line: i.line(), Message: msg})
+}
+
+func checkArgs(native string, args []interface{}, n int) {
+	if len(args) < n || len(args) > n+1 {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s() expects %d or %d arguments, got %d", native, n, n+1, len(args))})
+	}
+}
+
+// assert fails unless its argument is truthy. A second argument is a message
+// to fail with.
+func assert(i *Interpreter, args []interface{}) interface{} {
+	checkArgs("assert", args, 1)
+	if !isTruthy(args[0]) {
+		fail(i, "assert", args, 1, fmt.Sprintf("got %s", Repr(args[0])))
+	}
+	return nil
+}
+
+// assertEq fails unless its first two arguments are deeply equal, showing
+// where arrays and maps differ.
+func assertEq(i *Interpreter, args []interface{}) interface{} {
+	checkArgs("assert_eq", args, 2)
+	actual, expected := args[0], args[1]
+	if diffs := diff("", actual, expected); len(diffs) > 0 {
+		msg := fmt.Sprintf("expected %s, got %s", Repr(expected), Repr(actual))
+		if len(diffs) > 1 || diffs[0] != msg {
+			msg += "\n" + strings.Join(diffs, "\n")
+		}
+		fail(i, "assert_eq", args, 2, msg)
+	}
+	return nil
+}
+
+// assertThrows calls a function of no arguments and fails unless it raises a
+// runtime error, whose message it returns.
+func assertThrows(i *Interpreter, args []interface{}) interface{} {
+	checkArgs("assert_throws", args, 1)
+	fn, ok := args[0].(Callable)
+	if !ok || (fn.Arity() > 0) {
+		panic(&InterpreterError{Message: "assert_throws() argument 1 must be a function of no arguments"})
+	}
+
+	_, err := i.Call(fn)
+	var exit *ExitError
+	if errors.As(err, &exit) {
+		panic(err)
+	}
+	if err == nil {
+		fail(i, "assert_throws", args, 1, fmt.Sprintf("expected %s to raise an error", fn))
+	}
+	if assertion, ok := err.(*AssertionError); ok {
+		return "assertion failed: " + assertion.Message
+	}
+	return err.Error()
+}
+
+// diff describes where actual and expected differ, one line per difference,
+// or returns nil if they are deeply equal. path locates the values within the
+// outermost ones.
+func diff(path string, actual, expected interface{}) []string {
+	at := ""
+	if path != "" {
+		at = path + ": "
+	}
+
+	switch e := expected.(type) {
+	case *JazzArray:
+		a, ok := actual.(*JazzArray)
+		if !ok {
+			break
+		}
+		diffs := []string{}
+		for n := 0; n < len(a.Elements) || n < len(e.Elements); n++ {
+			elPath := fmt.Sprintf("%s[%d]", path, n)
+			switch {
+			case n >= len(a.Elements):
+				diffs = append(diffs, fmt.Sprintf("  %s: missing, expected %s", elPath, Repr(e.Elements[n])))
+			case n >= len(e.Elements):
+				diffs = append(diffs, fmt.Sprintf("  %s: unexpected %s", elPath, Repr(a.Elements[n])))
+			default:
+				diffs = append(diffs, diff(elPath, a.Elements[n], e.Elements[n])...)
+			}
+		}
+		return diffs
+	case *JazzMap:
+		a, ok := actual.(*JazzMap)
+		if !ok {
+			break
+		}
+		diffs := []string{}
+		for _, key := range e.Keys() {
+			elPath := fmt.Sprintf("%s[%q]", path, key)
+			ev, _ := e.Get(key)
+			av, ok := a.Get(key)
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("  %s: missing, expected %s", elPath, Repr(ev)))
+				continue
+			}
+			diffs = append(diffs, diff(elPath, av, ev)...)
+		}
+		for _, key := range a.Keys() {
+			if _, ok := e.Get(key); !ok {
+				av, _ := a.Get(key)
+				diffs = append(diffs, fmt.Sprintf("  %s[%q]: unexpected %s", path, key, Repr(av)))
+			}
+		}
+		return diffs
+	default:
+		if isEqual(actual, expected) {
+			return nil
+		}
+	}
+
+	if path == "" {
+		return []string{fmt.Sprintf("expected %s, got %s", Repr(expected), Repr(actual))}
+	}
+	return []string{fmt.Sprintf("  %sexpected %s, got %s", at, Repr(expected), Repr(actual))}
+}
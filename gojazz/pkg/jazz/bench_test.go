@@ -0,0 +1,106 @@
+package jazz
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type program struct {
+	name   string
+	source string
+}
+
+// programs returns the benchmark programs in testdata/bench, sorted by name.
+func programs(b *testing.B) []program {
+	files, err := filepath.Glob(filepath.Join("testdata", "bench", "*.jz"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	programs := []program{}
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		programs = append(programs, program{name: strings.TrimSuffix(filepath.Base(file), ".jz"), source: string(source)})
+	}
+	return programs
+}
+
+func scan(b *testing.B, source string) []*Token {
+	tokens, err := NewScanner(source).ScanTokens()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return tokens
+}
+
+// benchmark runs fn as a sub-benchmark for every program.
+func benchmark(b *testing.B, fn func(b *testing.B, source string)) {
+	for _, p := range programs(b) {
+		p := p
+		b.Run(p.name, func(b *testing.B) {
+			fn(b, p.source)
+		})
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	benchmark(b, func(b *testing.B, source string) {
+		b.SetBytes(int64(len(source)))
+		for n := 0; n < b.N; n++ {
+			scan(b, source)
+		}
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	benchmark(b, func(b *testing.B, source string) {
+		tokens := scan(b, source)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			if _, err := NewParser(tokens).Parse(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkResolve(b *testing.B) {
+	benchmark(b, func(b *testing.B, source string) {
+		stmts := parseSource(b, source)
+		interpreter := NewInterpreter(WithStdout(io.Discard))
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			if err := NewResolver(interpreter).Resolve(stmts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkInterpret runs each program in a fresh interpreter. Creating the
+// interpreter and resolving are included, since every run does both.
+func BenchmarkInterpret(b *testing.B) {
+	benchmark(b, func(b *testing.B, source string) {
+		stmts := parseSource(b, source)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			run(b, stmts)
+		}
+	})
+}
+
+func run(b *testing.B, stmts []Stmt) {
+	interpreter := NewInterpreter(WithStdout(io.Discard))
+	if err := NewResolver(interpreter).Resolve(stmts); err != nil {
+		b.Fatal(err)
+	}
+	if err := interpreter.Interpret(stmts); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package jazz
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Capability names group natives by what they give a script access to.
+const (
+	CapabilityCore   = "core"
+	CapabilityMath   = "math"
+	CapabilityString = "string"
+	CapabilityJSON   = "json"
+	CapabilityIO     = "io"
+	CapabilityTime   = "time"
+	CapabilityOS     = "os"
+	CapabilityTest   = "test"
+)
+
+// DefaultCapabilities are granted when no WithCapabilities option is given.
+// They cannot reach outside the interpreter.
+var DefaultCapabilities = []string{CapabilityCore, CapabilityMath, CapabilityString, CapabilityJSON, CapabilityTime}
+
+// AllCapabilities lists every capability, including those that let scripts
+// touch the host.
+var AllCapabilities = []string{CapabilityCore, CapabilityMath, CapabilityString, CapabilityJSON, CapabilityIO, CapabilityTime, CapabilityOS, CapabilityTest}
+
+var capabilities = map[string]map[string]Callable{
+	CapabilityCore: {
+		"freeze": &FreezeNative{},
+		"keys":   &KeysNative{},
+		"len":    &LenNative{},
+		"push":   &PushNative{},
+	},
+	CapabilityMath:   mathNatives,
+	CapabilityString: stringNatives,
+	CapabilityJSON:   jsonNatives,
+	CapabilityIO:     merge(ioNatives, fileNatives),
+	CapabilityTime: {
+		"clock": &Clock{},
+	},
+	CapabilityOS:   osNatives,
+	CapabilityTest: testNatives,
+}
+
+// WithCapabilities selects the capabilities whose natives are defined in the
+// global environment.
+func WithCapabilities(names ...string) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.capabilities = names
+	}
+}
+
+// CapabilityNames returns the names of all known capabilities, sorted.
+func CapabilityNames() []string {
+	names := make([]string, 0, len(capabilities))
+	for name := range capabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CapabilityNatives returns the natives a capability provides, keyed by name.
+func CapabilityNatives(name string) map[string]Callable {
+	return merge(capabilities[name])
+}
+
+func (i *Interpreter) defineCapabilities(names []string) {
+	for _, name := range names {
+		natives, ok := capabilities[name]
+		if !ok {
+			panic(fmt.Sprintf("jazz: unknown capability %q", name))
+		}
+		for native, fn := range natives {
+			i.globalEnv.Define(native, fn)
+		}
+	}
+}
+
+// nativeCapability returns the capability that provides the native called
+// name, if any.
+func nativeCapability(name string) (string, bool) {
+	for capability, natives := range capabilities {
+		if _, ok := natives[name]; ok {
+			return capability, true
+		}
+	}
+	return "", false
+}
+
+func merge(sets ...map[string]Callable) map[string]Callable {
+	merged := map[string]Callable{}
+	for _, set := range sets {
+		for name, native := range set {
+			merged[name] = native
+		}
+	}
+	return merged
+}
@@ -0,0 +1,135 @@
+package jazz
+
+import (
+	"fmt"
+	"io"
+)
+
+// Hook is called by an interpreter created WithHook before it runs each
+// statement, with the statement's frame already up to date. A non-nil error
+// stops the program and is returned from Interpret.
+type Hook interface {
+	Before(i *Interpreter, stmt Stmt) error
+}
+
+// CallHook is a Hook that is also told when Jazz functions are called and
+// return. A tail call returns from one function before calling the next.
+type CallHook interface {
+	Hook
+	Call(i *Interpreter, fn *Func)
+	Return(i *Interpreter, fn *Func)
+}
+
+// WithHook installs a hook, such as a debugger, that sees every statement
+// before it runs. Hooks are called in the order they are installed.
+func WithHook(hook Hook) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.hooks = append(cfg.hooks, hook)
+	}
+}
+
+// Frame is an active call of a Jazz function, or the top-level script.
+type Frame struct {
+	Func *Func // nil for the top-level script
+	Line int   // line of the statement running in the frame
+	Env  *Env  // innermost environment of the frame
+
+	stmt Stmt // statement running in the frame, see Line
+}
+
+// Name returns the name of the frame's function, or "<script>".
+func (f *Frame) Name() string {
+	if f.Func == nil {
+		return "<script>"
+	}
+	return f.Func.Declaration.Name.Lexeme
+}
+
+// Frames returns the active frames, innermost first.
+func (i *Interpreter) Frames() []*Frame {
+	frames := make([]*Frame, len(i.frames))
+	for n := range i.frames {
+		frame := i.frames[len(i.frames)-1-n]
+		if frame.stmt != nil {
+			frame.Line = StmtLine(frame.stmt)
+		}
+		frames[n] = &frame
+	}
+	return frames
+}
+
+// CallDepth returns how many Jazz function calls are active.
+func (i *Interpreter) CallDepth() int {
+	return i.callDepth
+}
+
+// Globals returns the environment of top-level declarations and natives.
+func (i *Interpreter) Globals() *Env {
+	return i.globalEnv
+}
+
+// Evaluate evaluates the expression in source where env is in scope, which is
+// usually the Env of a paused Frame. Names are looked up through
+// env's ancestors since the expression was never resolved, and hooks are not
+// called for functions it calls.
+func (i *Interpreter) Evaluate(source string, env *Env) (val interface{}, err error) {
+	tokens, err := NewScanner(source).ScanTokens()
+	if err != nil {
+		return nil, err
+	}
+	expr, err := NewParser(tokens, WithErrOutput(io.Discard)).ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	prevEnv, prevHooks, prevCalls, prevDynamic := i.env, i.hooks, i.calls, i.dynamic
+	i.env, i.hooks, i.calls, i.dynamic = env, nil, nil, true
+	defer func() {
+		i.env, i.hooks, i.calls, i.dynamic = prevEnv, prevHooks, prevCalls, prevDynamic
+	}()
+	defer recoverError(&err)
+
+	return i.eval(expr)
+}
+
+func (i *Interpreter) VisitDebuggerStmt(_ *DebuggerStmt) (interface{}, error) {
+	return nil, nil
+}
+
+// line returns the line of the statement running in the innermost frame.
+func (i *Interpreter) line() int {
+	if stmt := i.frames[len(i.frames)-1].stmt; stmt != nil {
+		return StmtLine(stmt)
+	}
+	return 0
+}
+
+func (i *Interpreter) call(fn *Func) {
+	for _, hook := range i.calls {
+		hook.Call(i, fn)
+	}
+}
+
+func (i *Interpreter) ret(fn *Func) {
+	for _, hook := range i.calls {
+		hook.Return(i, fn)
+	}
+}
+
+func (i *Interpreter) enterFrame(fn *Func) {
+	i.enterCall()
+	i.frames = append(i.frames, Frame{Func: fn})
+}
+
+func (i *Interpreter) exitFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+	i.exitCall()
+}
+
+// Repr formats a value the way a debugger shows it, quoting strings.
+func Repr(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", val)
+}
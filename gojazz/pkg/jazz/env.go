@@ -2,15 +2,31 @@ package jazz
 
 import (
 	"fmt"
+	"sort"
 )
 
-type Store map[string]interface{}
-
+// Env holds the variables of a scope in slots. The Resolver numbers the slots
+// of a local scope in the order its variables are declared, which is the order
+// they are defined in at runtime. The globals are the Env with no enclosing
+// Env: it numbers its own slots by name, see globalSlot, and they may be
+// undefined.
 type Env struct {
-	cfg   *EnvCfg
-	store Store
+	enclosing *Env
+	slots     []slot
+	consts    []bool         // by slot, nil while no slot is constant
+	index     map[string]int // slot by name, in the globals only
+	inline    [4]slot
+}
+
+type slot struct {
+	name string
+	val  interface{}
 }
 
+// undefined fills the global slots of names that are numbered but not
+// defined.
+var undefined interface{} = &struct{ undefined bool }{}
+
 type EnvOpt func(*EnvCfg)
 
 type EnvCfg struct {
@@ -30,64 +46,207 @@ func NewEnv(options ...EnvOpt) *Env {
 	for _, option := range options {
 		option(cfg)
 	}
-	return &Env{
-		cfg:   cfg,
-		store: map[string]interface{}{},
+	return newEnv(cfg.enclosing)
+}
+
+func newEnv(enclosing *Env) *Env {
+	env := &Env{enclosing: enclosing}
+	env.slots = env.inline[:0]
+	if enclosing == nil {
+		env.index = map[string]int{}
+	}
+	return env
+}
+
+func (e *Env) isGlobal() bool {
+	return e.enclosing == nil
+}
+
+// slot returns the slot holding name in e, or -1.
+func (e *Env) slot(name string) int {
+	if e.isGlobal() {
+		n, ok := e.index[name]
+		if !ok || e.slots[n].val == undefined {
+			return -1
+		}
+		return n
+	}
+
+	for n := range e.slots {
+		if e.slots[n].name == name {
+			return n
+		}
 	}
+	return -1
+}
+
+func (e *Env) isConst(n int) bool {
+	return n < len(e.consts) && e.consts[n]
 }
 
-func (e *Env) AssignAt(depth int, token *Token, val interface{}) error {
+// AssignAt assigns to the variable resolved to slot n of the Env depth levels
+// out from e.
+func (e *Env) AssignAt(depth, n int, token *Token, val interface{}) error {
 	env := e.ancestor(depth)
-	if env == nil {
-		return fmt.Errorf("could not find env at %v", depth)
+	if env == nil || n >= len(env.slots) {
+		return fmt.Errorf("could not find variable '%s' at %v", token.Lexeme, depth)
 	}
 
-	env.store[token.Lexeme] = val
+	if env.isConst(n) {
+		return fmt.Errorf("cannot assign to constant '%s'", token.Lexeme)
+	}
 
+	env.slots[n].val = val
 	return nil
 }
 
+// Assign assigns to a variable by name, looking through e and its ancestors.
 func (e *Env) Assign(token *Token, val interface{}) error {
-	if _, ok := e.store[token.Lexeme]; !ok {
-		if e.cfg.enclosing != nil {
-			return e.cfg.enclosing.Assign(token, val)
+	for env := e; env != nil; env = env.enclosing {
+		n := env.slot(token.Lexeme)
+		if n < 0 {
+			continue
+		}
+		if env.isConst(n) {
+			return fmt.Errorf("cannot assign to constant '%s'", token.Lexeme)
 		}
-		return fmt.Errorf("undefined variable '%s'", token)
+		env.slots[n].val = val
+		return nil
 	}
 
-	e.store[token.Lexeme] = val
-	return nil
+	return fmt.Errorf("undefined variable '%s'", token.Lexeme)
 }
 
+// Define defines name in the next slot of a local Env, or in the slot of
+// name in the globals.
 func (e *Env) Define(name string, val interface{}) {
-	e.store[name] = val
+	e.define(name, val)
 }
 
-func (e *Env) Get(token *Token) (interface{}, error) {
-	if val, ok := e.store[token.Lexeme]; ok {
-		return val, nil
+func (e *Env) define(name string, val interface{}) int {
+	if !e.isGlobal() {
+		e.slots = append(e.slots, slot{name: name, val: val})
+		return len(e.slots) - 1
 	}
 
-	if e.cfg.enclosing != nil {
-		return e.cfg.enclosing.Get(token)
+	n := e.globalSlot(name)
+	e.slots[n].val = val
+	return n
+}
+
+// globalSlot returns the slot of a global name, numbering it on first use.
+// The Resolver numbers the globals a tree uses in the globals of the
+// interpreter it resolves for.
+func (e *Env) globalSlot(name string) int {
+	n, ok := e.index[name]
+	if !ok {
+		n = len(e.slots)
+		e.index[name] = n
+		e.slots = append(e.slots, slot{name: name, val: undefined})
+	}
+	return n
+}
+
+// global returns the slot holding the defined global name, or -1. It tries
+// slot n first, falling back to the name for trees resolved for another
+// interpreter, whose globals are numbered differently.
+func (e *Env) global(n int, name string) int {
+	if n < len(e.slots) && e.slots[n].name == name && e.slots[n].val != undefined {
+		return n
+	}
+	return e.slot(name)
+}
+
+func (e *Env) DefineConst(name string, val interface{}) {
+	n := e.define(name, val)
+	for len(e.consts) <= n {
+		e.consts = append(e.consts, false)
+	}
+	e.consts[n] = true
+}
+
+func (e *Env) IsConst(name string) bool {
+	if e.consts == nil {
+		return false
+	}
+	n := e.slot(name)
+	return n >= 0 && e.isConst(n)
+}
+
+// Get looks up a variable by name, looking through e and its ancestors.
+func (e *Env) Get(token *Token) (interface{}, error) {
+	for env := e; env != nil; env = env.enclosing {
+		if n := env.slot(token.Lexeme); n >= 0 {
+			return env.slots[n].val, nil
+		}
 	}
 
 	return nil, fmt.Errorf("undefined variable '%s'", token.Lexeme)
 }
 
-func (e *Env) GetAt(depth int, name string) (interface{}, error) {
-	val, ok := e.ancestor(depth).store[name]
-	if !ok {
-		return nil, fmt.Errorf("could not find variable '%s' at %v", name, depth)
+// GetAt returns the variable resolved to slot n of the Env depth levels out
+// from e.
+func (e *Env) GetAt(depth, n int) (interface{}, error) {
+	env := e.ancestor(depth)
+	if env == nil || n >= len(env.slots) {
+		return nil, fmt.Errorf("could not find variable at %v, %v", depth, n)
 	}
 
-	return val, nil
+	return env.slots[n].val, nil
+}
+
+// getGlobal returns the global named by token, resolved to slot n, or
+// undefined.
+func (e *Env) getGlobal(n int, token *Token) interface{} {
+	if n = e.global(n, token.Lexeme); n < 0 {
+		return undefined
+	}
+	return e.slots[n].val
+}
+
+// assignGlobal assigns to the global named by token, resolved to slot n,
+// which must be defined.
+func (e *Env) assignGlobal(n int, token *Token, val interface{}) error {
+	if n = e.global(n, token.Lexeme); n < 0 {
+		return fmt.Errorf("undefined variable '%s'", token.Lexeme)
+	}
+	if e.isConst(n) {
+		return fmt.Errorf("cannot assign to constant '%s'", token.Lexeme)
+	}
+	e.slots[n].val = val
+	return nil
+}
+
+// Names returns the names defined directly in e, sorted.
+func (e *Env) Names() []string {
+	names := make([]string, 0, len(e.slots))
+	for _, s := range e.slots {
+		if s.val != undefined {
+			names = append(names, s.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the value of a name defined directly in e.
+func (e *Env) Lookup(name string) (interface{}, bool) {
+	n := e.slot(name)
+	if n < 0 {
+		return nil, false
+	}
+	return e.slots[n].val, true
+}
+
+// Enclosing returns the environment e is nested in, or nil for the globals.
+func (e *Env) Enclosing() *Env {
+	return e.enclosing
 }
 
 func (e *Env) ancestor(depth int) *Env {
 	env := e
 	for i := 0; i < depth; i++ {
-		env = env.cfg.enclosing
+		env = env.enclosing
 	}
 
 	return env
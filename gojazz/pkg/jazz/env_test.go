@@ -0,0 +1,96 @@
+package jazz
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func parseSource(tb testing.TB, source string) []Stmt {
+	tb.Helper()
+	tokens, err := NewScanner(source).ScanTokens()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	stmts, err := NewParser(tokens, WithErrOutput(io.Discard)).Parse()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return stmts
+}
+
+func TestGlobalsAreNumberedPerInterpreter(t *testing.T) {
+	fresh := len(NewInterpreter().globalEnv.slots)
+
+	var source strings.Builder
+	for n := 0; n < 1000; n++ {
+		fmt.Fprintf(&source, "print global%d;\n", n)
+	}
+	interpreter := NewInterpreter()
+	if err := NewResolver(interpreter).Resolve(parseSource(t, source.String())); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(interpreter.globalEnv.slots); got < fresh+1000 {
+		t.Errorf("resolving numbered %d globals, want at least %d", got, fresh+1000)
+	}
+
+	if got := len(NewInterpreter().globalEnv.slots); got != fresh {
+		t.Errorf("a new interpreter has %d global slots after another resolved a program, want %d", got, fresh)
+	}
+}
+
+func TestTreeRunsInAnotherInterpreter(t *testing.T) {
+	stmts := parseSource(t, `let a = 1; let b = 2; b = b + 1; print a + b;`)
+
+	// The other interpreter numbers b before a.
+	other := NewInterpreter()
+	if err := NewResolver(other).Resolve(parseSource(t, `let z; let b; print z == b;`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewResolver(other).Resolve(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	interpreter := NewInterpreter(WithStdout(&out))
+	if err := interpreter.Interpret(stmts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "4\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFuncAsBodyKeepsLaterSlots(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"an if that is not taken", `fn t(c) { let a = "a"; if (c) fn g() {} let b = "b"; let d = "d"; print b; } t(false);`, "b\n"},
+		{"an if that is taken", `fn t(c) { let a = "a"; if (c) fn g() {} let b = "b"; let d = "d"; print b; } t(true);`, "b\n"},
+		{"an else", `fn t(c) { if (c) print c; else fn g() {} let b = "b"; print b; } t(false);`, "b\n"},
+		{"a while that runs many times", `fn t() { let n = 0; while ((n = n + 1) < 3) fn g() {} let b = "b"; print b + n; } t();`, "b3\n"},
+		{"a while that never runs", `fn t() { let a = "a"; while (false) fn g() {} let b = "b"; print a + b; } t();`, "ab\n"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			interpreter := NewInterpreter(WithStdout(&out))
+			stmts := parseSource(t, test.source)
+			if err := NewResolver(interpreter).Resolve(stmts); err != nil {
+				t.Fatal(err)
+			}
+			if err := interpreter.Interpret(stmts); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -5,11 +5,14 @@ type ExprVisitor interface {
 	VisitAssignExpr(expr *AssignExpr) (interface{}, error)
 	VisitBinExpr(expr *BinExpr) (interface{}, error)
 	VisitCallExpr(expr *CallExpr) (interface{}, error)
+	VisitConditionalExpr(expr *ConditionalExpr) (interface{}, error)
+	VisitErrorExpr(expr *ErrorExpr) (interface{}, error)
 	VisitGroupingExpr(expr *GroupingExpr) (interface{}, error)
 	VisitIndexGetExpr(expr *IndexGetExpr) (interface{}, error)
 	VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, error)
 	VisitLiteralExpr(expr *LiteralExpr) (interface{}, error)
 	VisitLogicalExpr(expr *LogicalExpr) (interface{}, error)
+	VisitMapExpr(expr *MapExpr) (interface{}, error)
 	VisitUnaryExpr(expr *UnaryExpr) (interface{}, error)
 	VisitVarExpr(expr *VarExpr) (interface{}, error)
 }
@@ -25,8 +28,10 @@ type ArrayExpr struct {
 
 type AssignExpr struct {
 	Name     *Token
-	Operator *Token
+	Operator *Token // '=', a compound operator such as '+=', or '++'/'--'
 	Val      Expr
+	Postfix  bool     // x++ and x-- evaluate to the value before the update
+	Binding  *Binding // set by the Resolver
 }
 
 type BinExpr struct {
@@ -41,6 +46,19 @@ type CallExpr struct {
 	Args   []Expr
 }
 
+type ConditionalExpr struct {
+	Condition Expr
+	Question  *Token
+	Then      Expr
+	Else      Expr
+}
+
+// ErrorExpr stands in for an expression that failed to parse in recovery
+// mode. Tokens are the tokens skipped over.
+type ErrorExpr struct {
+	Tokens []*Token
+}
+
 type GroupingExpr struct {
 	Expr Expr
 }
@@ -55,6 +73,12 @@ type LogicalExpr struct {
 	Left     Expr
 }
 
+type MapExpr struct {
+	Brace *Token
+	Keys  []*Token
+	Vals  []Expr
+}
+
 type UnaryExpr struct {
 	Right    Expr
 	Operator *Token
@@ -67,14 +91,25 @@ type IndexGetExpr struct {
 }
 
 type IndexSetExpr struct {
-	Object  Expr
-	Index   Expr
-	Val     Expr
-	Bracket *Token
+	Object   Expr
+	Index    Expr
+	Val      Expr
+	Bracket  *Token
+	Operator *Token // '=', a compound operator such as '+=', or '++'/'--'
+	Postfix  bool   // a[i]++ and a[i]-- evaluate to the value before the update
 }
 
 type VarExpr struct {
-	Name *Token
+	Name    *Token
+	Binding *Binding // set by the Resolver
+}
+
+// Binding is where the Resolver found a variable: in Slot of the Env Depth
+// levels out from the innermost, or if Depth is -1 in Slot of the globals of
+// the interpreter it resolved for.
+type Binding struct {
+	Depth int
+	Slot  int
 }
 
 func (expr *ArrayExpr) Accept(v ExprVisitor) (interface{}, error) {
@@ -85,6 +120,10 @@ func (expr *AssignExpr) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitAssignExpr(expr)
 }
 
+func (expr *ErrorExpr) Accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitErrorExpr(expr)
+}
+
 func (expr *BinExpr) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitBinExpr(expr)
 }
@@ -93,6 +132,10 @@ func (expr *CallExpr) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitCallExpr(expr)
 }
 
+func (expr *ConditionalExpr) Accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitConditionalExpr(expr)
+}
+
 func (expr *GroupingExpr) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitGroupingExpr(expr)
 }
@@ -105,6 +148,10 @@ func (expr *LogicalExpr) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitLogicalExpr(expr)
 }
 
+func (expr *MapExpr) Accept(v ExprVisitor) (interface{}, error) {
+	return v.VisitMapExpr(expr)
+}
+
 func (expr *UnaryExpr) Accept(v ExprVisitor) (interface{}, error) {
 	return v.VisitUnaryExpr(expr)
 }
@@ -0,0 +1,237 @@
+package jazz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var fileNatives = map[string]Callable{
+	"read_file":   &Native{Name: "read_file", Params: 1, Fn: readFile},
+	"write_file":  &Native{Name: "write_file", Params: 2, Fn: writeFile},
+	"append_file": &Native{Name: "append_file", Params: 2, Fn: appendFile},
+	"list_dir":    &Native{Name: "list_dir", Params: 1, Fn: listDir},
+	"exists":      &Native{Name: "exists", Params: 1, Fn: exists},
+	"stat":        &Native{Name: "stat", Params: 1, Fn: stat},
+	"remove":      &Native{Name: "remove", Params: 1, Fn: remove},
+	"mkdir":       &Native{Name: "mkdir", Params: 1, Fn: mkdir},
+}
+
+// WithRoot jails the file system natives to dir: paths are resolved relative
+// to it and may not escape it, not even through symbolic links.
+func WithRoot(dir string) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.root = dir
+	}
+}
+
+// maxLinks bounds how many symbolic links a path may go through, as the host
+// does, so that link cycles end.
+const maxLinks = 255
+
+// resolvePath maps a path given by a script to a host path, enforcing the
+// root jail if one is configured. The returned path has every symbolic link
+// resolved, so the operation runs on exactly the path that was checked.
+func (i *Interpreter) resolvePath(native string, path string) string {
+	if i.cfg.root == "" {
+		return path
+	}
+
+	root := i.jailRoot(native)
+	full, err := jailPath(root, path)
+	if err != nil {
+		panic(fileError(native, path, err))
+	}
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s(\"%s\"): path escapes the root directory", native, path)})
+	}
+
+	return full
+}
+
+// jailRoot returns the root directory as an absolute path free of symbolic
+// links.
+func (i *Interpreter) jailRoot(native string) string {
+	root, err := filepath.Abs(i.cfg.root)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s(): invalid root directory: %s", native, err)})
+	}
+	return root
+}
+
+// jailPath resolves path as if root were the file system root, following
+// symbolic links one component at a time with Lstat and Readlink. Unlike
+// filepath.EvalSymlinks it also follows links whose target does not exist,
+// and it keeps components that do not exist yet.
+func jailPath(root, path string) (string, error) {
+	sep := string(filepath.Separator)
+	parts := strings.Split(filepath.Clean(sep+path), sep)
+	current := root
+	links := 0
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, part)
+		info, err := os.Lstat(next)
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			current = next
+			continue
+		}
+
+		links++
+		if links > maxLinks {
+			return "", errors.New("too many levels of symbolic links")
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			current = filepath.VolumeName(target) + sep
+		}
+		parts = append(strings.Split(filepath.Clean(target), sep), parts...)
+	}
+
+	return current, nil
+}
+
+// fileError reports a failed file operation in terms of the path the script
+// used rather than the host path.
+func fileError(native string, path string, err error) *InterpreterError {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return &InterpreterError{Message: fmt.Sprintf("%s(\"%s\"): %s", native, path, err)}
+}
+
+// readFile charges the size of the file against the allocation budget before
+// reading it, so that WithMaxAlloc bounds host memory too.
+func readFile(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("read_file", args, 0)
+	f, err := os.Open(i.resolvePath("read_file", path))
+	if err != nil {
+		panic(fileError("read_file", path, err))
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		panic(fileError("read_file", path, err))
+	}
+	if err := i.allocate(int(info.Size())); err != nil {
+		panic(err)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(f, info.Size()+1))
+	if err != nil {
+		panic(fileError("read_file", path, err))
+	}
+	if int64(len(b)) > info.Size() {
+		panic(fileError("read_file", path, errors.New("file grew while reading")))
+	}
+	return string(b)
+}
+
+func writeFile(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("write_file", args, 0)
+	err := os.WriteFile(i.resolvePath("write_file", path), []byte(stringify(args[1])), 0o644)
+	if err != nil {
+		panic(fileError("write_file", path, err))
+	}
+	return nil
+}
+
+func appendFile(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("append_file", args, 0)
+	f, err := os.OpenFile(i.resolvePath("append_file", path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		panic(fileError("append_file", path, err))
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(stringify(args[1])); err != nil {
+		panic(fileError("append_file", path, err))
+	}
+	return nil
+}
+
+func listDir(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("list_dir", args, 0)
+	entries, err := os.ReadDir(i.resolvePath("list_dir", path))
+	if err != nil {
+		panic(fileError("list_dir", path, err))
+	}
+
+	if err := i.allocate(len(entries) * valueSize); err != nil {
+		panic(err)
+	}
+	names := make([]interface{}, len(entries))
+	for n, entry := range entries {
+		names[n] = entry.Name()
+	}
+	return NewJazzArray(names)
+}
+
+func exists(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("exists", args, 0)
+	_, err := os.Stat(i.resolvePath("exists", path))
+	return err == nil
+}
+
+// stat returns a map with the name, size, is_dir, mode and modified (in
+// milliseconds since the epoch) of a file.
+func stat(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("stat", args, 0)
+	info, err := os.Stat(i.resolvePath("stat", path))
+	if err != nil {
+		panic(fileError("stat", path, err))
+	}
+
+	if err := i.allocate(5 * valueSize); err != nil {
+		panic(err)
+	}
+	m := NewJazzMap()
+	m.Set("name", info.Name())
+	m.Set("size", float64(info.Size()))
+	m.Set("is_dir", info.IsDir())
+	m.Set("mode", info.Mode().String())
+	m.Set("modified", float64(info.ModTime().UnixMilli()))
+	return m
+}
+
+func remove(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("remove", args, 0)
+	full := i.resolvePath("remove", path)
+	if i.cfg.root != "" && full == i.jailRoot("remove") {
+		panic(&InterpreterError{Message: fmt.Sprintf("remove(\"%s\"): cannot remove the root directory", path)})
+	}
+	if err := os.Remove(full); err != nil {
+		panic(fileError("remove", path, err))
+	}
+	return nil
+}
+
+func mkdir(i *Interpreter, args []interface{}) interface{} {
+	path := stringArg("mkdir", args, 0)
+	if err := os.MkdirAll(i.resolvePath("mkdir", path), 0o755); err != nil {
+		panic(fileError("mkdir", path, err))
+	}
+	return nil
+}
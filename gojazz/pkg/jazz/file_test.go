@@ -0,0 +1,115 @@
+package jazz
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRootJail(t *testing.T) {
+	dir := t.TempDir()
+	jail := filepath.Join(dir, "jail")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{jail, outside, filepath.Join(jail, "sub")} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(jail, "sub", "inner"), []byte("inner"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"abs":      outside,
+		"rel":      "../outside",
+		"dangling": filepath.Join(outside, "created"),
+		"within":   "sub",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(jail, name)); err != nil {
+			t.Skip("symbolic links are not supported:", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		msg    string
+	}{
+		{"dot dot", `print read_file("../outside/secret");`, `read_file("../outside/secret"): no such file or directory`},
+		{"absolute path", `print read_file("` + filepath.ToSlash(filepath.Join(outside, "secret")) + `");`, "no such file or directory"},
+		{"symlink out of the root", `print read_file("abs/secret");`, `read_file("abs/secret"): path escapes the root directory`},
+		{"relative symlink out of the root", `print read_file("rel/secret");`, `read_file("rel/secret"): path escapes the root directory`},
+		{"dangling symlink", `write_file("dangling", "pwned");`, `write_file("dangling"): path escapes the root directory`},
+		{"remove the root", `remove("/");`, `remove("/"): cannot remove the root directory`},
+		{"remove the empty path", `remove("");`, `remove(""): cannot remove the root directory`},
+		{"remove the root through dot dot", `remove("sub/..");`, `remove("sub/.."): cannot remove the root directory`},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runSource(t, test.source, WithRoot(jail), WithCapabilities(CapabilityCore, CapabilityIO), WithStdout(&stdout))
+			if err == nil {
+				t.Fatalf("script finished, want it stopped; printed %q", stdout.String())
+			}
+			if !strings.Contains(err.Error(), test.msg) {
+				t.Errorf("got %q, want it to contain %q", err.Error(), test.msg)
+			}
+			if strings.Contains(stdout.String(), "secret") {
+				t.Errorf("script read outside the root: %q", stdout.String())
+			}
+		})
+	}
+
+	if _, err := os.Lstat(filepath.Join(outside, "created")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("write through the dangling symlink created its target: %v", err)
+	}
+	if _, err := os.Stat(jail); err != nil {
+		t.Errorf("root was removed: %v", err)
+	}
+}
+
+func TestRootJailAllowsPathsWithinIt(t *testing.T) {
+	jail := t.TempDir()
+	if err := os.Mkdir(filepath.Join(jail, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub", filepath.Join(jail, "within")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+
+	source := `
+		write_file("/within/a", "a");
+		print read_file("sub/a");
+		print read_file("sub/../within/./a");
+		remove("within/a");
+		print exists("sub/a");`
+	var stdout bytes.Buffer
+	err := runSource(t, source, WithRoot(jail), WithCapabilities(CapabilityCore, CapabilityIO), WithStdout(&stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "a\na\nfalse\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadFileChargesItsSize(t *testing.T) {
+	jail := t.TempDir()
+	if err := os.WriteFile(filepath.Join(jail, "big"), make([]byte, 1<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runSource(t, `read_file("big");`, WithRoot(jail), WithCapabilities(CapabilityCore, CapabilityIO), WithMaxAlloc(1<<16))
+	var e *AllocLimitError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %T %v, want an allocation limit error", err, err)
+	}
+}
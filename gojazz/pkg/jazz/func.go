@@ -9,7 +9,7 @@ type Func struct {
 	EnclosingEnv *Env
 }
 
-func NewFunc(name *Token, params []*Token, body []Stmt, enclosingEnv *Env) *Func {
+func NewFunc(name *Token, params []Pattern, body []Stmt, enclosingEnv *Env) *Func {
 	fs := &FuncStmt{Name: name, Params: params, Body: body}
 
 	return &Func{Declaration: fs, EnclosingEnv: enclosingEnv}
@@ -20,22 +20,44 @@ func (f *Func) Arity() int {
 }
 
 func (f *Func) Call(i *Interpreter, args ...interface{}) interface{} {
+	i.enterFrame(f)
+	defer i.exitFrame()
+
 	enclosingEnv := i.env
-	env := NewEnv(WithEnclosingEnv(f.EnclosingEnv))
-	for i, param := range f.Declaration.Params {
-		env.Define(param.Lexeme, args[i])
+	fn := f
+	if len(i.calls) > 0 {
+		defer func() { i.ret(fn) }()
 	}
+	for {
+		i.frames[len(i.frames)-1].Func = fn
+		i.call(fn)
+		env := newEnv(fn.EnclosingEnv)
+		for i, param := range fn.Declaration.Params {
+			if binding, ok := param.(*BindingPattern); ok {
+				env.Define(binding.Name.Lexeme, args[i])
+				continue
+			}
+			if err := definePattern(env, param, args[i], false); err != nil {
+				panic(err)
+			}
+		}
 
-	_, err := i.executeBlock(f.Declaration.Body, env)
-	if err != nil {
-		if rerr, ok := err.(*ReturnError); ok {
-			i.env = enclosingEnv
-			return rerr.Val
+		_, err := i.executeBlock(fn.Declaration.Body, env)
+		if err != nil {
+			if rerr, ok := err.(*ReturnError); ok {
+				i.env = enclosingEnv
+				return rerr.Val
+			}
+			if tail, ok := err.(*TailCallError); ok {
+				i.ret(fn)
+				fn, args = tail.Func, tail.Args
+				continue
+			}
+			panic(err)
 		}
-		panic(err)
-	}
 
-	return nil
+		return nil
+	}
 }
 
 func (f *Func) String() string {
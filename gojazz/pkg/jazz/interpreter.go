@@ -1,11 +1,17 @@
 package jazz
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 
 	"github.com/thepatrik/strcolor"
 )
@@ -18,6 +24,17 @@ func (err *ReturnError) Error() string {
 	return fmt.Sprintf("return %s", err.Val)
 }
 
+// TailCallError unwinds a function body on 'return f(args);' so that the
+// calling Func can reuse its frame for f instead of growing the Go stack.
+type TailCallError struct {
+	Func *Func
+	Args []interface{}
+}
+
+func (err *TailCallError) Error() string {
+	return fmt.Sprintf("tail call %s", err.Func)
+}
+
 type InterpreterError struct {
 	Message string
 }
@@ -26,18 +43,48 @@ func (err *InterpreterError) Error() string {
 	return err.Message
 }
 
+type StackOverflowError struct {
+	Depth int
+}
+
+func (err *StackOverflowError) Error() string {
+	return fmt.Sprintf("stack overflow: maximum call depth of %d exceeded", err.Depth)
+}
+
+const DefaultMaxCallDepth = 10000
+
 type InterpreterOpt func(*InterpreterCfg)
 
 type InterpreterCfg struct {
-	logger *log.Logger
-	repl   bool
+	logger       *log.Logger
+	stdout       io.Writer
+	stderr       io.Writer
+	stdin        io.Reader
+	repl         bool
+	maxCallDepth int
+	ctx          context.Context
+	capabilities []string
+	root         string
+	args         []string
+	maxSteps     int64
+	maxAlloc     int64
+	maxOutput    int64
+	hooks        []Hook
 }
 
 type Interpreter struct {
 	cfg       *InterpreterCfg
+	stdin     *bufio.Reader
 	env       *Env
 	globalEnv *Env
-	locals    map[Expr]int
+	frames    []Frame
+	hooks     []Hook
+	calls     []CallHook // the hooks that are CallHooks
+	dynamic   bool       // look up unresolved names through env, see Evaluate
+	callDepth int
+	steps     int64
+	allocated int64
+	written   int64
 }
 
 func WithRepl(repl bool) InterpreterOpt {
@@ -52,49 +99,185 @@ func WithLogger(logger *log.Logger) InterpreterOpt {
 	}
 }
 
+// WithStdout sets where print and the REPL echo write.
+func WithStdout(w io.Writer) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.stdout = w
+	}
+}
+
+// WithStderr sets where errors and warnings are reported.
+func WithStderr(w io.Writer) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.stderr = w
+	}
+}
+
+// WithStdin sets where input natives read from.
+func WithStdin(r io.Reader) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.stdin = r
+	}
+}
+
+// WithMaxCallDepth limits how deeply Jazz function calls may nest before the
+// interpreter raises a StackOverflowError. Tail calls do not count.
+func WithMaxCallDepth(depth int) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.maxCallDepth = depth
+	}
+}
+
 func NewInterpreter(options ...InterpreterOpt) *Interpreter {
 	cfg := &InterpreterCfg{
-		logger: log.New(os.Stdout, "", 0),
-		repl:   false,
+		stdout:       os.Stdout,
+		stderr:       os.Stderr,
+		stdin:        os.Stdin,
+		repl:         false,
+		maxCallDepth: DefaultMaxCallDepth,
+		ctx:          context.Background(),
+		capabilities: DefaultCapabilities,
 	}
 	for _, option := range options {
 		option(cfg)
 	}
+	if cfg.logger == nil {
+		cfg.logger = log.New(cfg.stdout, "", 0)
+	}
 
 	env := NewEnv()
 	globalEnv := env
 
-	globalEnv.Define("clock", &Clock{})
-	globalEnv.Define("len", &LenNative{})
-	globalEnv.Define("push", &PushNative{})
+	interpreter := &Interpreter{cfg: cfg, env: env, globalEnv: globalEnv, frames: []Frame{{Env: globalEnv}}}
+	interpreter.defineCapabilities(cfg.capabilities)
+	interpreter.hooks = cfg.hooks
+	for _, hook := range cfg.hooks {
+		if call, ok := hook.(CallHook); ok {
+			interpreter.calls = append(interpreter.calls, call)
+		}
+	}
+
+	args := make([]interface{}, len(cfg.args))
+	for n, arg := range cfg.args {
+		args[n] = arg
+	}
+	globalEnv.Define("args", NewJazzArray(args))
+
+	return interpreter
+}
+
+func (i *Interpreter) Stdout() io.Writer {
+	return i.cfg.stdout
+}
+
+func (i *Interpreter) Stderr() io.Writer {
+	return i.cfg.stderr
+}
+
+// readLine reads a line from the interpreter's stdin without its line ending.
+// It returns false at the end of the input.
+func (i *Interpreter) readLine() (string, bool) {
+	if i.stdin == nil {
+		i.stdin = bufio.NewReader(i.cfg.stdin)
+	}
+
+	line, err := i.stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
 
-	return &Interpreter{cfg: cfg, env: env, globalEnv: globalEnv, locals: make(map[Expr]int)}
+	return strings.TrimRight(line, "\r\n"), true
 }
 
-func (i *Interpreter) Interpret(stmts []Stmt) {
+// Interpret runs stmts until the first runtime error, which is returned.
+// Errors raised by natives and nested calls are recovered here as well.
+func (i *Interpreter) Interpret(stmts []Stmt) (err error) {
+	defer recoverError(&err)
+
 	for _, stmt := range stmts {
-		_, _ = i.Run(stmt)
+		_, err := i.Run(stmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recoverError turns a panicking Jazz error into *err. Go runtime errors keep
+// panicking.
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		rerr, ok := r.(error)
+		if _, isRuntime := r.(runtime.Error); !ok || isRuntime {
+			panic(r)
+		}
+		*err = rerr
 	}
 }
 
 func (i *Interpreter) Run(stmt Stmt) (interface{}, error) {
+	if err := i.step(); err != nil {
+		return nil, err
+	}
+	frame := &i.frames[len(i.frames)-1]
+	frame.stmt, frame.Env = stmt, i.env
+	if len(i.hooks) > 0 {
+		for _, hook := range i.hooks {
+			if err := hook.Before(i, stmt); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return stmt.Accept(i)
 }
 
-func (interpreter *Interpreter) Resolve(expr Expr, depth int) error {
-	interpreter.locals[expr] = depth
-	return nil
+// Call calls fn with args, returning any runtime error it raises.
+func (i *Interpreter) Call(fn Callable, args ...interface{}) (val interface{}, err error) {
+	defer recoverError(&err)
+	return fn.Call(i, args...), nil
 }
 
 func stringify(i interface{}) string {
 	return fmt.Sprintf("%v", i)
 }
 
+// typeName describes the Jazz type of a value for error messages.
+func typeName(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case float64, int64, int:
+		return "number"
+	case string:
+		return "string"
+	case *JazzArray:
+		return "array"
+	case *JazzMap:
+		return "map"
+	case Callable:
+		return "function"
+	}
+
+	return fmt.Sprintf("%T", val)
+}
+
 func (i *Interpreter) VisitBlockStmt(stmt *BlockStmt) (interface{}, error) {
-	env := NewEnv(WithEnclosingEnv(i.env))
+	env := newEnv(i.env)
 	return i.executeBlock(stmt.Stmts, env)
 }
 
+// runBody runs the body of an if or while statement, giving a function
+// declared as the body its own Env, as the Resolver gives it its own scope.
+func (i *Interpreter) runBody(stmt Stmt) (interface{}, error) {
+	if _, ok := stmt.(*FuncStmt); ok {
+		return i.executeBlock([]Stmt{stmt}, newEnv(i.env))
+	}
+	return i.Run(stmt)
+}
+
 func (i *Interpreter) executeBlock(stmts []Stmt, env *Env) (interface{}, error) {
 	prev := i.env
 	i.env = env
@@ -108,6 +291,23 @@ func (i *Interpreter) executeBlock(stmts []Stmt, env *Env) (interface{}, error)
 	return nil, nil
 }
 
+func (i *Interpreter) VisitErrorStmt(stmt *ErrorStmt) (interface{}, error) {
+	return nil, syntaxError(stmt.Tokens)
+}
+
+func (i *Interpreter) VisitErrorExpr(expr *ErrorExpr) (interface{}, error) {
+	return nil, syntaxError(expr.Tokens)
+}
+
+// syntaxError is raised when a tree parsed WithRecovery is run anyway.
+func syntaxError(tokens []*Token) error {
+	line := 0
+	if len(tokens) > 0 {
+		line = tokens[0].Line
+	}
+	return &InterpreterError{Message: fmt.Sprintf("[line %d] cannot run code with syntax errors", line)}
+}
+
 func (i *Interpreter) VisitExprStmt(stmt *ExprStmt) (interface{}, error) {
 	val, err := i.eval(stmt.Expr)
 	if err != nil {
@@ -132,9 +332,28 @@ func (i *Interpreter) VisitIfStmt(stmt *IfStmt) (interface{}, error) {
 	}
 
 	if isTruthy(val) {
-		return i.Run(stmt.ThenStmt)
+		return i.runBody(stmt.ThenStmt)
 	} else if stmt.ElseStmt != nil {
-		return i.Run(stmt.ElseStmt)
+		return i.runBody(stmt.ElseStmt)
+	}
+
+	return nil, nil
+}
+
+func (i *Interpreter) VisitMatchStmt(stmt *MatchStmt) (interface{}, error) {
+	val, err := i.eval(stmt.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range stmt.Arms {
+		for _, pattern := range arm.Patterns {
+			env := newEnv(i.env)
+			if err := definePattern(env, pattern, val, false); err != nil {
+				continue
+			}
+			return i.executeBlock([]Stmt{arm.Body}, env)
+		}
 	}
 
 	return nil, nil
@@ -146,11 +365,26 @@ func (i *Interpreter) VisitPrintStmt(stmt *PrintStmt) (interface{}, error) {
 		return nil, err
 	}
 
-	fmt.Printf("%v\n", val)
+	line := fmt.Sprintf("%v\n", val)
+	if err := i.output(len(line)); err != nil {
+		return nil, err
+	}
+	fmt.Fprint(i.cfg.stdout, line)
 	return nil, nil
 }
 
 func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) (interface{}, error) {
+	if call, ok := stmt.Val.(*CallExpr); ok && i.callDepth > 0 {
+		fn, args, err := i.evalCall(call)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := fn.(*Func); ok {
+			return nil, &TailCallError{Func: f, Args: args}
+		}
+		return nil, &ReturnError{Val: fn.Call(i, args...)}
+	}
+
 	var val interface{}
 	if stmt.Val != nil {
 		var err error
@@ -173,7 +407,24 @@ func (i *Interpreter) VisitVarStmt(stmt *VarStmt) (interface{}, error) {
 		}
 	}
 
-	i.env.Define(stmt.Name.Lexeme, val)
+	if stmt.Pattern != nil {
+		for _, name := range patternNames(stmt.Pattern) {
+			if i.env.IsConst(name.Lexeme) {
+				return nil, &InterpreterError{Message: fmt.Sprintf("cannot redeclare constant '%s'", name.Lexeme)}
+			}
+		}
+		return nil, definePattern(i.env, stmt.Pattern, val, stmt.Const)
+	}
+
+	if i.env.IsConst(stmt.Name.Lexeme) {
+		return nil, &InterpreterError{Message: fmt.Sprintf("cannot redeclare constant '%s'", stmt.Name.Lexeme)}
+	}
+
+	if stmt.Const {
+		i.env.DefineConst(stmt.Name.Lexeme, val)
+	} else {
+		i.env.Define(stmt.Name.Lexeme, val)
+	}
 	return nil, nil
 }
 
@@ -187,6 +438,9 @@ func (i *Interpreter) VisitContinueStmt(_ *ContinueStmt) (interface{}, error) {
 
 func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) (interface{}, error) {
 	for {
+		if err := i.step(); err != nil {
+			return nil, err
+		}
 		val, err := i.eval(stmt.Condition)
 		if err != nil {
 			return nil, err
@@ -194,7 +448,7 @@ func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) (interface{}, error) {
 		if !isTruthy(val) {
 			break
 		}
-		_, err = i.Run(stmt.Body)
+		_, err = i.runBody(stmt.Body)
 		if err != nil {
 			if _, ok := err.(*BreakError); ok {
 				break
@@ -218,7 +472,73 @@ func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) (interface{}, error) {
 	return nil, nil
 }
 
+func (i *Interpreter) VisitForInStmt(stmt *ForInStmt) (interface{}, error) {
+	iterable, err := i.eval(stmt.Iterable)
+	if err != nil {
+		return nil, err
+	}
+
+	var next func(n int) (interface{}, bool)
+	switch v := iterable.(type) {
+	case *JazzArray:
+		next = func(n int) (interface{}, bool) {
+			if n >= len(v.Elements) {
+				return nil, false
+			}
+			return v.Elements[n], true
+		}
+	case *JazzMap:
+		keys := append([]string{}, v.Keys()...)
+		next = func(n int) (interface{}, bool) {
+			if n >= len(keys) {
+				return nil, false
+			}
+			return keys[n], true
+		}
+	case string:
+		chars := []rune(v)
+		next = func(n int) (interface{}, bool) {
+			if n >= len(chars) {
+				return nil, false
+			}
+			return string(chars[n]), true
+		}
+	default:
+		return nil, &InterpreterError{Message: fmt.Sprintf("cannot iterate over %s", typeName(iterable))}
+	}
+
+	for n := 0; ; n++ {
+		if err := i.step(); err != nil {
+			return nil, err
+		}
+		val, ok := next(n)
+		if !ok {
+			break
+		}
+
+		env := newEnv(i.env)
+		if err := definePattern(env, stmt.Pattern, val, stmt.Const); err != nil {
+			return nil, err
+		}
+
+		_, err = i.executeBlock([]Stmt{stmt.Body}, env)
+		if err != nil {
+			if _, ok := err.(*BreakError); ok {
+				break
+			}
+			if _, ok := err.(*ContinueError); ok {
+				continue
+			}
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 func (i *Interpreter) VisitArrayExpr(expr *ArrayExpr) (interface{}, error) {
+	if err := i.allocate(len(expr.Elements) * valueSize); err != nil {
+		return nil, err
+	}
 	elements := make([]interface{}, 0, len(expr.Elements))
 	for _, el := range expr.Elements {
 		val, err := i.eval(el)
@@ -230,24 +550,41 @@ func (i *Interpreter) VisitArrayExpr(expr *ArrayExpr) (interface{}, error) {
 	return NewJazzArray(elements), nil
 }
 
+func (i *Interpreter) VisitMapExpr(expr *MapExpr) (interface{}, error) {
+	if err := i.allocate(len(expr.Keys) * valueSize); err != nil {
+		return nil, err
+	}
+	m := NewJazzMap()
+	for n, key := range expr.Keys {
+		val, err := i.eval(expr.Vals[n])
+		if err != nil {
+			return nil, err
+		}
+		m.Set(mapKey(key), val)
+	}
+	return m, nil
+}
+
 func (i *Interpreter) VisitIndexGetExpr(expr *IndexGetExpr) (interface{}, error) {
 	obj, err := i.eval(expr.Object)
 	if err != nil {
 		return nil, err
 	}
-	arr, ok := obj.(*JazzArray)
-	if !ok {
-		panic(&InterpreterError{Message: "Can only index arrays."})
-	}
 	idxVal, err := i.eval(expr.Index)
 	if err != nil {
 		return nil, err
 	}
-	idx := int(idxVal.(float64))
-	if idx < 0 || idx >= len(arr.Elements) {
-		panic(&InterpreterError{Message: fmt.Sprintf("Index %d out of bounds (length %d).", idx, len(arr.Elements))})
+
+	switch v := obj.(type) {
+	case *JazzArray:
+		idx := arrayIndex(v, idxVal)
+		return v.Elements[idx], nil
+	case *JazzMap:
+		val, _ := v.Get(mapIndex(idxVal))
+		return val, nil
 	}
-	return arr.Elements[idx], nil
+
+	panic(&InterpreterError{Message: "Can only index arrays and maps."})
 }
 
 func (i *Interpreter) VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, error) {
@@ -255,39 +592,121 @@ func (i *Interpreter) VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, error)
 	if err != nil {
 		return nil, err
 	}
-	arr, ok := obj.(*JazzArray)
-	if !ok {
-		panic(&InterpreterError{Message: "Can only index arrays."})
-	}
 	idxVal, err := i.eval(expr.Index)
 	if err != nil {
 		return nil, err
 	}
-	idx := int(idxVal.(float64))
-	if idx < 0 || idx >= len(arr.Elements) {
-		panic(&InterpreterError{Message: fmt.Sprintf("Index %d out of bounds (length %d).", idx, len(arr.Elements))})
+
+	var get func() interface{}
+	var set func(val interface{})
+	switch v := obj.(type) {
+	case *JazzArray:
+		idx := arrayIndex(v, idxVal)
+		if v.Frozen {
+			panic(&InterpreterError{Message: "Cannot modify a frozen array."})
+		}
+		get = func() interface{} { return v.Elements[idx] }
+		set = func(val interface{}) { v.Elements[idx] = val }
+	case *JazzMap:
+		key := mapIndex(idxVal)
+		if v.Frozen {
+			panic(&InterpreterError{Message: "Cannot modify a frozen map."})
+		}
+		if _, ok := v.Get(key); !ok {
+			if err := i.allocate(valueSize); err != nil {
+				return nil, err
+			}
+		}
+		get = func() interface{} {
+			val, _ := v.Get(key)
+			return val
+		}
+		set = func(val interface{}) { v.Set(key, val) }
+	default:
+		panic(&InterpreterError{Message: "Can only index arrays and maps."})
+	}
+
+	op, compound := compoundOp(expr.Operator)
+	var current interface{}
+	if compound {
+		current = get()
 	}
 	val, err := i.eval(expr.Val)
 	if err != nil {
 		return nil, err
 	}
-	arr.Elements[idx] = val
+	if compound {
+		val, err = i.binary(op, current, val)
+		if err != nil {
+			return nil, err
+		}
+	}
+	set(val)
+	if expr.Postfix {
+		return current, nil
+	}
 	return val, nil
 }
 
+func arrayIndex(arr *JazzArray, idxVal interface{}) int {
+	f, ok := idxVal.(float64)
+	if !ok {
+		panic(&InterpreterError{Message: fmt.Sprintf("Array index must be a number but was %s.", typeName(idxVal))})
+	}
+	idx := int(f)
+	if idx < 0 || idx >= len(arr.Elements) {
+		panic(&InterpreterError{Message: fmt.Sprintf("Index %d out of bounds (length %d).", idx, len(arr.Elements))})
+	}
+	return idx
+}
+
+func mapIndex(idxVal interface{}) string {
+	key, ok := idxVal.(string)
+	if !ok {
+		panic(&InterpreterError{Message: fmt.Sprintf("Map key must be a string but was %s.", typeName(idxVal))})
+	}
+	return key
+}
+
 func (i *Interpreter) VisitAssignExpr(expr *AssignExpr) (interface{}, error) {
+	op, compound := compoundOp(expr.Operator)
+	var current interface{}
+	if compound {
+		var err error
+		current, err = i.lookupVar(expr.Name, expr.Binding)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	val, err := i.eval(expr.Val)
 	if err != nil {
 		return nil, err
 	}
+	if compound {
+		val, err = i.binary(op, current, val)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	depth, ok := i.locals[expr]
-	if ok {
-		_ = i.env.AssignAt(depth, expr.Name, val)
-	} else {
-		_ = i.globalEnv.Assign(expr.Name, val)
+	switch b := expr.Binding; {
+	case b == nil && i.dynamic:
+		err = i.env.Assign(expr.Name, val)
+	case b == nil:
+		err = i.globalEnv.Assign(expr.Name, val)
+	case b.Depth < 0:
+		err = i.globalEnv.assignGlobal(b.Slot, expr.Name, val)
+	default:
+		err = i.env.AssignAt(b.Depth, b.Slot, expr.Name, val)
+	}
+	if err != nil {
+		return nil, &InterpreterError{Message: err.Error()}
 	}
 
+	if expr.Postfix {
+		return current, nil
+	}
 	return val, nil
 }
 
@@ -302,7 +721,31 @@ func (i *Interpreter) VisitBinExpr(expr *BinExpr) (interface{}, error) {
 		return nil, err
 	}
 
-	switch expr.Operator.TokenType {
+	return i.binary(expr.Operator.TokenType, left, right)
+}
+
+// compoundOps maps compound assignment and increment operators to the binary
+// operator they apply.
+var compoundOps = map[TokenType]TokenType{
+	TokenTypePlusEq:     TokenTypePlus,
+	TokenTypeMinusEq:    TokenTypeMinus,
+	TokenTypeStarEq:     TokenTypeStar,
+	TokenTypeSlashEq:    TokenTypeSlash,
+	TokenTypePercentEq:  TokenTypePercent,
+	TokenTypePlusPlus:   TokenTypePlus,
+	TokenTypeMinusMinus: TokenTypeMinus,
+}
+
+func compoundOp(operator *Token) (TokenType, bool) {
+	if operator == nil {
+		return 0, false
+	}
+	op, ok := compoundOps[operator.TokenType]
+	return op, ok
+}
+
+func (i *Interpreter) binary(op TokenType, left, right interface{}) (interface{}, error) {
+	switch op {
 	case TokenTypeBangEq:
 		return !isEqual(left, right), nil
 	case TokenTypeEqEq:
@@ -359,19 +802,29 @@ func (i *Interpreter) VisitBinExpr(expr *BinExpr) (interface{}, error) {
 			return nil, errors.New("invalid operation: division by zero")
 		}
 		return l / r, nil
+	case TokenTypePercent:
+		l, r, err := toFloat64s(left, right)
+		if err != nil {
+			return nil, err
+		}
+
+		if r == 0 {
+			return nil, errors.New("invalid operation: modulo by zero")
+		}
+		return math.Mod(l, r), nil
 	case TokenTypePlus:
 		if leftStr, ok := left.(string); ok {
 			if rightStr, ok := right.(string); ok {
-				return leftStr + rightStr, nil
+				return i.allocString(leftStr + rightStr)
 			} else {
-				return leftStr + stringify(right), nil
+				return i.allocString(leftStr + stringify(right))
 			}
 		}
 		if rightStr, ok := right.(string); ok {
 			if leftStr, ok := left.(string); ok {
-				return leftStr + rightStr, nil
+				return i.allocString(leftStr + rightStr)
 			} else {
-				return stringify(left) + rightStr, nil
+				return i.allocString(stringify(left) + rightStr)
 			}
 		}
 
@@ -386,31 +839,71 @@ func (i *Interpreter) VisitBinExpr(expr *BinExpr) (interface{}, error) {
 	return nil, nil
 }
 
+func (i *Interpreter) allocString(s string) (interface{}, error) {
+	if err := i.allocate(len(s)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 func (i *Interpreter) VisitCallExpr(stmt *CallExpr) (interface{}, error) {
-	callee, err := i.eval(stmt.Callee)
+	fn, args, err := i.evalCall(stmt)
 	if err != nil {
 		return nil, err
 	}
 
+	return fn.Call(i, args...), nil
+}
+
+// evalCall evaluates the callee and arguments of a call without calling it.
+func (i *Interpreter) evalCall(stmt *CallExpr) (Callable, []interface{}, error) {
+	callee, err := i.eval(stmt.Callee)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	fn, ok := callee.(Callable)
 	if !ok {
-		panic(InterpreterError{Message: "callee is not a function"})
+		panic(&InterpreterError{Message: "callee is not a function"})
 	}
 
 	args := make([]interface{}, 0)
 	for _, arg := range stmt.Args {
 		val, err := i.eval(arg)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		args = append(args, val)
 	}
 
-	if fn.Arity() != len(args) {
-		panic(InterpreterError{Message: fmt.Sprintf("wrong number of arguments: expected %d, got %d", fn.Arity(), len(args))})
+	if fn.Arity() >= 0 && fn.Arity() != len(args) {
+		panic(&InterpreterError{Message: fmt.Sprintf("wrong number of arguments: expected %d, got %d", fn.Arity(), len(args))})
 	}
 
-	return fn.Call(i, args...), nil
+	return fn, args, nil
+}
+
+func (i *Interpreter) enterCall() {
+	if i.callDepth >= i.cfg.maxCallDepth {
+		panic(&StackOverflowError{Depth: i.cfg.maxCallDepth})
+	}
+	i.callDepth++
+}
+
+func (i *Interpreter) exitCall() {
+	i.callDepth--
+}
+
+func (i *Interpreter) VisitConditionalExpr(expr *ConditionalExpr) (interface{}, error) {
+	val, err := i.eval(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(val) {
+		return i.eval(expr.Then)
+	}
+	return i.eval(expr.Else)
 }
 
 func (i *Interpreter) VisitGroupingExpr(expr *GroupingExpr) (interface{}, error) {
@@ -461,7 +954,7 @@ func (i *Interpreter) VisitUnaryExpr(expr *UnaryExpr) (interface{}, error) {
 }
 
 func (i *Interpreter) VisitVarExpr(expr *VarExpr) (interface{}, error) {
-	return i.lookupVar(expr.Name, expr)
+	return i.lookupVar(expr.Name, expr.Binding)
 }
 
 func (i *Interpreter) eval(expr Expr) (interface{}, error) {
@@ -512,11 +1005,31 @@ func toFloat64(val interface{}) (float64, error) {
 	return 0, fmt.Errorf("invalid operation: operand must be a number but was a %T[%v]", val, val)
 }
 
-func (interpreter *Interpreter) lookupVar(token *Token, expr Expr) (interface{}, error) {
-	dist, ok := interpreter.locals[expr]
-	if ok {
-		return interpreter.env.GetAt(dist, token.Lexeme)
+// lookupVar returns the value of the variable named by token. Names the
+// Resolver never saw are looked up in the globals, or through the current
+// environment while evaluating, see Evaluate.
+func (interpreter *Interpreter) lookupVar(token *Token, binding *Binding) (interface{}, error) {
+	if binding != nil && binding.Depth >= 0 {
+		return interpreter.env.GetAt(binding.Depth, binding.Slot)
+	}
+
+	var val interface{}
+	var err error
+	switch {
+	case binding != nil:
+		if val = interpreter.globalEnv.getGlobal(binding.Slot, token); val == undefined {
+			val, err = nil, fmt.Errorf("undefined variable '%s'", token.Lexeme)
+		}
+	case interpreter.dynamic:
+		val, err = interpreter.env.Get(token)
+	default:
+		val, err = interpreter.globalEnv.Get(token)
+	}
+	if err != nil {
+		if capability, ok := nativeCapability(token.Lexeme); ok {
+			return nil, &InterpreterError{Message: fmt.Sprintf("native '%s' is not available: it requires the '%s' capability", token.Lexeme, capability)}
+		}
 	}
 
-	return interpreter.globalEnv.Get(token)
+	return val, err
 }
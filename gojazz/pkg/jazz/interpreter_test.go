@@ -0,0 +1,36 @@
+package jazz
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// BenchmarkFib30 runs examples/measure_clock.jz, which spends nearly all its
+// time calling fib and looking up variables.
+func BenchmarkFib30(b *testing.B) {
+	source, err := os.ReadFile(filepath.Join("..", "..", "..", "examples", "measure_clock.jz"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	tokens, err := NewScanner(string(source)).ScanTokens()
+	if err != nil {
+		b.Fatal(err)
+	}
+	stmts, err := NewParser(tokens).Parse()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		interpreter := NewInterpreter(WithStdout(io.Discard))
+		if err := NewResolver(interpreter).Resolve(stmts); err != nil {
+			b.Fatal(err)
+		}
+		if err := interpreter.Interpret(stmts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
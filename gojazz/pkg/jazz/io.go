@@ -0,0 +1,38 @@
+package jazz
+
+import "fmt"
+
+var ioNatives = map[string]Callable{
+	"input":     &Native{Name: "input", Params: -1, Fn: input},
+	"read_line": &Native{Name: "read_line", Params: 0, Fn: readLine},
+}
+
+// input writes an optional prompt and reads a line, returning nil at the end
+// of the input.
+func input(i *Interpreter, args []interface{}) interface{} {
+	if len(args) > 1 {
+		panic(&InterpreterError{Message: fmt.Sprintf("input() expects at most one argument, got %d", len(args))})
+	}
+	if len(args) == 1 {
+		prompt := stringify(args[0])
+		if err := i.output(len(prompt)); err != nil {
+			panic(err)
+		}
+		fmt.Fprint(i.cfg.stdout, prompt)
+	}
+
+	return readLine(i, nil)
+}
+
+func readLine(i *Interpreter, _ []interface{}) interface{} {
+	line, ok := i.readLine()
+	if !ok {
+		return nil
+	}
+
+	s, err := i.allocString(line)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
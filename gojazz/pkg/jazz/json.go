@@ -0,0 +1,218 @@
+package jazz
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var jsonNatives = map[string]Callable{
+	"json_parse":     &Native{Name: "json_parse", Params: 1, Fn: jsonParse},
+	"json_stringify": &Native{Name: "json_stringify", Params: -1, Fn: jsonStringify},
+}
+
+// jsonParse decodes a JSON document into arrays, maps, numbers, strings,
+// bools and nil. Object keys keep the order they appear in.
+func jsonParse(i *Interpreter, args []interface{}) interface{} {
+	source := stringArg("json_parse", args, 0)
+	if err := i.allocate(len(source)); err != nil {
+		panic(err)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(source))
+	dec.UseNumber()
+	val, err := decodeJSON(dec)
+	if err != nil {
+		panic(&InterpreterError{Message: fmt.Sprintf("json_parse(): %s", err)})
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		panic(&InterpreterError{Message: "json_parse(): unexpected data after JSON value"})
+	}
+
+	return val
+}
+
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err == io.EOF {
+		return nil, errors.New("unexpected end of JSON input")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '[':
+			elements := []interface{}{}
+			for dec.More() {
+				el, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, el)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return NewJazzArray(elements), nil
+		case '{':
+			m := NewJazzMap()
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				val, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				m.Set(key.(string), val)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return m, nil
+		}
+		return nil, fmt.Errorf("unexpected %s", t)
+	case json.Number:
+		return t.Float64()
+	}
+
+	return token, nil
+}
+
+// maxJSONIndent is the most spaces json_stringify indents by, as in
+// JavaScript's JSON.stringify.
+const maxJSONIndent = 10
+
+// jsonStringify encodes a value as JSON. The optional second argument is the
+// number of spaces, or the string, to indent nested values with.
+func jsonStringify(i *Interpreter, args []interface{}) interface{} {
+	if len(args) < 1 || len(args) > 2 {
+		panic(&InterpreterError{Message: fmt.Sprintf("json_stringify() expects 1 or 2 arguments, got %d", len(args))})
+	}
+
+	enc := &jsonEncoder{visiting: map[interface{}]bool{}}
+	if len(args) == 2 {
+		switch indent := args[1].(type) {
+		case nil:
+		case string:
+			enc.indent = indent
+		default:
+			n := numberArg("json_stringify", args, 1)
+			if n < 0 || n > maxJSONIndent || n != float64(int(n)) {
+				panic(&InterpreterError{Message: fmt.Sprintf("json_stringify() indent must be a whole number from 0 to %d", maxJSONIndent)})
+			}
+			enc.indent = strings.Repeat(" ", int(n))
+		}
+	}
+
+	if err := enc.encode(args[0], 0); err != nil {
+		panic(&InterpreterError{Message: fmt.Sprintf("json_stringify(): %s", err)})
+	}
+
+	s, err := i.allocString(enc.buf.String())
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type jsonEncoder struct {
+	buf      bytes.Buffer
+	indent   string
+	visiting map[interface{}]bool
+}
+
+func (enc *jsonEncoder) encode(val interface{}, depth int) error {
+	switch v := val.(type) {
+	case nil:
+		enc.buf.WriteString("null")
+	case bool, float64, int64, int:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("cannot encode %v", v)
+		}
+		enc.buf.Write(b)
+	case string:
+		return enc.encodeString(v)
+	case *JazzArray:
+		if enc.visiting[v] {
+			return errors.New("cannot encode cyclic structure")
+		}
+		enc.visiting[v] = true
+		defer delete(enc.visiting, v)
+
+		enc.buf.WriteByte('[')
+		for n, el := range v.Elements {
+			if n > 0 {
+				enc.buf.WriteByte(',')
+			}
+			enc.newline(depth + 1)
+			if err := enc.encode(el, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(v.Elements) > 0 {
+			enc.newline(depth)
+		}
+		enc.buf.WriteByte(']')
+	case *JazzMap:
+		if enc.visiting[v] {
+			return errors.New("cannot encode cyclic structure")
+		}
+		enc.visiting[v] = true
+		defer delete(enc.visiting, v)
+
+		enc.buf.WriteByte('{')
+		for n, key := range v.Keys() {
+			if n > 0 {
+				enc.buf.WriteByte(',')
+			}
+			enc.newline(depth + 1)
+			if err := enc.encodeString(key); err != nil {
+				return err
+			}
+			enc.buf.WriteByte(':')
+			if enc.indent != "" {
+				enc.buf.WriteByte(' ')
+			}
+			el, _ := v.Get(key)
+			if err := enc.encode(el, depth+1); err != nil {
+				return err
+			}
+		}
+		if v.Len() > 0 {
+			enc.newline(depth)
+		}
+		enc.buf.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode %s", typeName(val))
+	}
+
+	return nil
+}
+
+func (enc *jsonEncoder) encodeString(s string) error {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(s); err != nil {
+		return err
+	}
+	enc.buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return nil
+}
+
+func (enc *jsonEncoder) newline(depth int) {
+	if enc.indent == "" {
+		return
+	}
+	enc.buf.WriteByte('\n')
+	enc.buf.WriteString(strings.Repeat(enc.indent, depth))
+}
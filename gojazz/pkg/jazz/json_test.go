@@ -0,0 +1,38 @@
+package jazz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONStringifyIndent(t *testing.T) {
+	tests := []struct {
+		indent string
+		want   string
+	}{
+		{"nil", "[1]\n"},
+		{"0", "[1]\n"},
+		{"2", "[\n  1\n]\n"},
+		{`"--"`, "[\n--1\n]\n"},
+		{"-1", "indent must be a whole number from 0 to 10"},
+		{"1.5", "indent must be a whole number from 0 to 10"},
+		{"11", "indent must be a whole number from 0 to 10"},
+		{"1000000000000000000000", "indent must be a whole number from 0 to 10"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.indent, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runSource(t, "print json_stringify([1], "+test.indent+");", WithStdout(&out))
+			got := out.String()
+			if err != nil {
+				got = err.Error()
+			}
+			if !strings.Contains(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package jazz
+
+import (
+	"context"
+	"fmt"
+)
+
+// valueSize is the number of bytes charged against the allocation budget for
+// every array element or map entry.
+const valueSize = 16
+
+// CanceledError is returned when the interpreter's context is canceled or
+// its deadline passes.
+type CanceledError struct {
+	Err error
+}
+
+func (err *CanceledError) Error() string {
+	return fmt.Sprintf("execution canceled: %s", err.Err)
+}
+
+func (err *CanceledError) Unwrap() error {
+	return err.Err
+}
+
+// StepLimitError is returned when a script executes more statements and loop
+// iterations than allowed by WithMaxSteps.
+type StepLimitError struct {
+	Limit int64
+}
+
+func (err *StepLimitError) Error() string {
+	return fmt.Sprintf("step limit of %d exceeded", err.Limit)
+}
+
+// AllocLimitError is returned when a script allocates more array, map and
+// string memory than allowed by WithMaxAlloc.
+type AllocLimitError struct {
+	Limit int64
+}
+
+func (err *AllocLimitError) Error() string {
+	return fmt.Sprintf("allocation limit of %d bytes exceeded", err.Limit)
+}
+
+// OutputLimitError is returned when a script prints more than allowed by
+// WithMaxOutput.
+type OutputLimitError struct {
+	Limit int64
+}
+
+func (err *OutputLimitError) Error() string {
+	return fmt.Sprintf("output limit of %d bytes exceeded", err.Limit)
+}
+
+// WithContext stops execution with a CanceledError once ctx is done.
+func WithContext(ctx context.Context) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.ctx = ctx
+	}
+}
+
+// WithMaxSteps limits the number of statements and loop iterations executed
+// over the lifetime of the interpreter. Zero means no limit.
+func WithMaxSteps(steps int64) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.maxSteps = steps
+	}
+}
+
+// WithMaxAlloc limits the approximate number of bytes allocated for arrays,
+// maps and strings over the lifetime of the interpreter. Zero means no limit.
+func WithMaxAlloc(bytes int64) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.maxAlloc = bytes
+	}
+}
+
+// WithMaxOutput limits the number of bytes written by print over the lifetime
+// of the interpreter. Zero means no limit.
+func WithMaxOutput(bytes int64) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.maxOutput = bytes
+	}
+}
+
+// step accounts for one statement or loop iteration.
+func (i *Interpreter) step() error {
+	if done := i.cfg.ctx.Done(); done != nil {
+		select {
+		case <-done:
+			return &CanceledError{Err: i.cfg.ctx.Err()}
+		default:
+		}
+	}
+
+	i.steps++
+	if i.cfg.maxSteps > 0 && i.steps > i.cfg.maxSteps {
+		return &StepLimitError{Limit: i.cfg.maxSteps}
+	}
+
+	return nil
+}
+
+// allocate accounts for n bytes of array, map or string memory.
+func (i *Interpreter) allocate(n int) error {
+	i.allocated += int64(n)
+	if i.cfg.maxAlloc > 0 && i.allocated > i.cfg.maxAlloc {
+		return &AllocLimitError{Limit: i.cfg.maxAlloc}
+	}
+
+	return nil
+}
+
+// output accounts for n bytes of printed output.
+func (i *Interpreter) output(n int) error {
+	i.written += int64(n)
+	if i.cfg.maxOutput > 0 && i.written > i.cfg.maxOutput {
+		return &OutputLimitError{Limit: i.cfg.maxOutput}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package jazz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// runSource scans, parses, resolves and interprets source, returning the first
+// error.
+func runSource(t *testing.T, source string, options ...InterpreterOpt) error {
+	t.Helper()
+	tokens, err := NewScanner(source).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts, err := NewParser(tokens, WithErrOutput(io.Discard)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	interpreter := NewInterpreter(append([]InterpreterOpt{WithStdout(io.Discard)}, options...)...)
+	if err := NewResolver(interpreter).Resolve(stmts); err != nil {
+		t.Fatal(err)
+	}
+	return interpreter.Interpret(stmts)
+}
+
+func TestLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelExpired()
+
+	tests := []struct {
+		name   string
+		source string
+		option InterpreterOpt
+		check  func(err error) bool
+		msg    string
+	}{
+		{
+			name:   "steps stop an infinite loop",
+			source: "while (true) {}",
+			option: WithMaxSteps(1000),
+			check:  func(err error) bool { var e *StepLimitError; return errors.As(err, &e) && e.Limit == 1000 },
+			msg:    "step limit of 1000 exceeded",
+		},
+		{
+			name:   "steps stop unbounded recursion in tail position",
+			source: "fn f() { return f(); } f();",
+			option: WithMaxSteps(1000),
+			check:  func(err error) bool { var e *StepLimitError; return errors.As(err, &e) },
+			msg:    "step limit of 1000 exceeded",
+		},
+		{
+			name:   "alloc stops string doubling",
+			source: `let s = "x"; while (true) { s = s + s; }`,
+			option: WithMaxAlloc(1 << 20),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) && e.Limit == 1<<20 },
+			msg:    "allocation limit of 1048576 bytes exceeded",
+		},
+		{
+			name:   "alloc stops a growing array",
+			source: "let a = []; while (true) { push(a, [1, 2, 3]); }",
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings upper returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) upper(s);`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings lower returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) lower(s);`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings trim returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) trim(s);`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings substr returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) substr(s, 0, len(s));`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings split returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) split(s, "");`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings replace returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) replace(s, "x", "y");`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings join returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) join([s], "");`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "alloc counts the strings str returns",
+			source: `let s = "x"; for (let n = 0; n < 10; n++) s = s + s; for (let n = 0; n < 1000; n++) str(s);`,
+			option: WithMaxAlloc(1 << 16),
+			check:  func(err error) bool { var e *AllocLimitError; return errors.As(err, &e) },
+			msg:    "allocation limit of 65536 bytes exceeded",
+		},
+		{
+			name:   "output stops a print loop",
+			source: `while (true) { print "spam"; }`,
+			option: WithMaxOutput(100),
+			check:  func(err error) bool { var e *OutputLimitError; return errors.As(err, &e) && e.Limit == 100 },
+			msg:    "output limit of 100 bytes exceeded",
+		},
+		{
+			name:   "a canceled context stops the script",
+			source: "while (true) {}",
+			option: WithContext(canceled),
+			check:  func(err error) bool { return errors.Is(err, context.Canceled) },
+			msg:    "execution canceled: context canceled",
+		},
+		{
+			name:   "a deadline stops an infinite loop",
+			source: "while (true) {}",
+			option: WithContext(expired),
+			check:  func(err error) bool { return errors.Is(err, context.DeadlineExceeded) },
+			msg:    "execution canceled: context deadline exceeded",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := runSource(t, test.source, test.option)
+			if err == nil {
+				t.Fatal("script finished, want it stopped")
+			}
+			if !test.check(err) {
+				t.Errorf("got %T %v", err, err)
+			}
+			if err.Error() != test.msg {
+				t.Errorf("got message %q, want %q", err.Error(), test.msg)
+			}
+		})
+	}
+}
+
+func TestLimitsAllowScriptsWithinThem(t *testing.T) {
+	source := `let s = ""; for (let i = 0; i < 10; i++) { s = s + "x"; print s; }`
+	err := runSource(t, source, WithMaxSteps(1000), WithMaxAlloc(1<<10), WithMaxOutput(1<<10), WithContext(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
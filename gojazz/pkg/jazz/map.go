@@ -0,0 +1,67 @@
+package jazz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JazzMap is a map from string keys to values that remembers the order in
+// which keys were first inserted.
+type JazzMap struct {
+	keys   []string
+	values map[string]interface{}
+	Frozen bool
+}
+
+func NewJazzMap() *JazzMap {
+	return &JazzMap{keys: []string{}, values: map[string]interface{}{}}
+}
+
+func (m *JazzMap) Get(key string) (interface{}, bool) {
+	val, ok := m.values[key]
+	return val, ok
+}
+
+func (m *JazzMap) Set(key string, val interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = val
+}
+
+// Keys returns the keys in insertion order.
+func (m *JazzMap) Keys() []string {
+	return m.keys
+}
+
+func (m *JazzMap) Len() int {
+	return len(m.keys)
+}
+
+// Freeze makes the map, and every array or map nested in it, read-only.
+func (m *JazzMap) Freeze() {
+	if m.Frozen {
+		return
+	}
+	m.Frozen = true
+	for _, key := range m.keys {
+		freeze(m.values[key])
+	}
+}
+
+func (m *JazzMap) String() string {
+	parts := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		parts[i] = fmt.Sprintf("%s: %v", key, m.values[key])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// mapKey returns the key named by an identifier or string token in a map
+// literal or map pattern.
+func mapKey(token *Token) string {
+	if s, ok := token.Literal.(string); ok && token.TokenType == TokenTypeString {
+		return s
+	}
+	return token.Lexeme
+}
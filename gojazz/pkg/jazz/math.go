@@ -0,0 +1,47 @@
+package jazz
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+var mathNatives = map[string]Callable{
+	"abs":    unaryMath("abs", math.Abs),
+	"ceil":   unaryMath("ceil", math.Ceil),
+	"floor":  unaryMath("floor", math.Floor),
+	"round":  unaryMath("round", math.Round),
+	"sqrt":   unaryMath("sqrt", math.Sqrt),
+	"pow":    &Native{Name: "pow", Params: 2, Fn: pow},
+	"min":    &Native{Name: "min", Params: -1, Fn: extremum("min", math.Min)},
+	"max":    &Native{Name: "max", Params: -1, Fn: extremum("max", math.Max)},
+	"random": &Native{Name: "random", Params: 0, Fn: random},
+}
+
+func unaryMath(name string, fn func(float64) float64) *Native {
+	return &Native{Name: name, Params: 1, Fn: func(_ *Interpreter, args []interface{}) interface{} {
+		return fn(numberArg(name, args, 0))
+	}}
+}
+
+func pow(_ *Interpreter, args []interface{}) interface{} {
+	return math.Pow(numberArg("pow", args, 0), numberArg("pow", args, 1))
+}
+
+// extremum folds fn over one or more numbers.
+func extremum(name string, fn func(float64, float64) float64) func(*Interpreter, []interface{}) interface{} {
+	return func(_ *Interpreter, args []interface{}) interface{} {
+		if len(args) == 0 {
+			panic(&InterpreterError{Message: fmt.Sprintf("%s() expects at least one argument", name)})
+		}
+		result := numberArg(name, args, 0)
+		for n := 1; n < len(args); n++ {
+			result = fn(result, numberArg(name, args, n))
+		}
+		return result
+	}
+}
+
+func random(_ *Interpreter, _ []interface{}) interface{} {
+	return rand.Float64()
+}
@@ -1,5 +1,10 @@
 package jazz
 
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
 // ---- Sentinel signals for break/continue -----------------------------------
 
 type BreakError struct{}
@@ -18,10 +23,12 @@ func (l *LenNative) Call(_ *Interpreter, args ...interface{}) interface{} {
 	switch v := args[0].(type) {
 	case *JazzArray:
 		return float64(len(v.Elements))
+	case *JazzMap:
+		return float64(v.Len())
 	case string:
-		return float64(len(v))
+		return float64(utf8.RuneCountInString(v))
 	}
-	panic(&InterpreterError{Message: "len() argument must be an array or string"})
+	panic(&InterpreterError{Message: "len() argument must be an array, map or string"})
 }
 
 func (l *LenNative) String() string { return "<native fn>" }
@@ -32,13 +39,94 @@ type PushNative struct{}
 
 func (p *PushNative) Arity() int { return 2 }
 
-func (p *PushNative) Call(_ *Interpreter, args ...interface{}) interface{} {
+func (p *PushNative) Call(interpreter *Interpreter, args ...interface{}) interface{} {
 	arr, ok := args[0].(*JazzArray)
 	if !ok {
 		panic(&InterpreterError{Message: "push() first argument must be an array"})
 	}
+	if arr.Frozen {
+		panic(&InterpreterError{Message: "push() cannot modify a frozen array"})
+	}
+	if err := interpreter.allocate(valueSize); err != nil {
+		panic(err)
+	}
 	arr.Elements = append(arr.Elements, args[1])
 	return float64(len(arr.Elements))
 }
 
 func (p *PushNative) String() string { return "<native fn>" }
+
+// ---- freeze() native -------------------------------------------------------
+
+type FreezeNative struct{}
+
+func (f *FreezeNative) Arity() int { return 1 }
+
+func (f *FreezeNative) Call(_ *Interpreter, args ...interface{}) interface{} {
+	freeze(args[0])
+	return args[0]
+}
+
+func (f *FreezeNative) String() string { return "<native fn>" }
+
+// ---- keys() native ---------------------------------------------------------
+
+type KeysNative struct{}
+
+func (k *KeysNative) Arity() int { return 1 }
+
+func (k *KeysNative) Call(_ *Interpreter, args ...interface{}) interface{} {
+	m, ok := args[0].(*JazzMap)
+	if !ok {
+		panic(&InterpreterError{Message: "keys() argument must be a map"})
+	}
+	keys := make([]interface{}, m.Len())
+	for i, key := range m.Keys() {
+		keys[i] = key
+	}
+	return NewJazzArray(keys)
+}
+
+func (k *KeysNative) String() string { return "<native fn>" }
+
+// ---- Native adapter --------------------------------------------------------
+
+// Native adapts a Go function to a Callable. Params is the number of
+// arguments the native takes, or -1 if it checks its arguments itself.
+type Native struct {
+	Name   string
+	Params int
+	Fn     func(interpreter *Interpreter, args []interface{}) interface{}
+}
+
+func (n *Native) Arity() int { return n.Params }
+
+func (n *Native) Call(interpreter *Interpreter, args ...interface{}) interface{} {
+	return n.Fn(interpreter, args)
+}
+
+func (n *Native) String() string { return "<native fn>" }
+
+func numberArg(native string, args []interface{}, n int) float64 {
+	f, err := toFloat64(args[n])
+	if _, isString := args[n].(string); err != nil || isString {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s() argument %d must be a number", native, n+1)})
+	}
+	return f
+}
+
+func stringArg(native string, args []interface{}, n int) string {
+	s, ok := args[n].(string)
+	if !ok {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s() argument %d must be a string", native, n+1)})
+	}
+	return s
+}
+
+func arrayArg(native string, args []interface{}, n int) *JazzArray {
+	arr, ok := args[n].(*JazzArray)
+	if !ok {
+		panic(&InterpreterError{Message: fmt.Sprintf("%s() argument %d must be an array", native, n+1)})
+	}
+	return arr
+}
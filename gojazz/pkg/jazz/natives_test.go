@@ -0,0 +1,23 @@
+package jazz
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLenCountsCharacters(t *testing.T) {
+	var out bytes.Buffer
+	source := `let s = "héllo wörld";
+print len(s);
+print substr(s, 0, len(s)) == s;
+let n = 0;
+for (let c in s) n++;
+print n == len(s);
+`
+	if err := runSource(t, source, WithStdout(&out)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "11\ntrue\ntrue\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package jazz
+
+import (
+	"fmt"
+	"os"
+)
+
+var osNatives = map[string]Callable{
+	"env":     &Native{Name: "env", Params: 1, Fn: getEnv},
+	"set_env": &Native{Name: "set_env", Params: 2, Fn: setEnv},
+	"exit":    &Native{Name: "exit", Params: -1, Fn: exit},
+}
+
+// ExitError unwinds the interpreter when a script calls exit().
+type ExitError struct {
+	Code int
+}
+
+func (err *ExitError) Error() string {
+	return fmt.Sprintf("exit %d", err.Code)
+}
+
+// WithArgs defines the global 'args' as an array of the given command-line
+// arguments.
+func WithArgs(args []string) InterpreterOpt {
+	return func(cfg *InterpreterCfg) {
+		cfg.args = args
+	}
+}
+
+func getEnv(_ *Interpreter, args []interface{}) interface{} {
+	val, ok := os.LookupEnv(stringArg("env", args, 0))
+	if !ok {
+		return nil
+	}
+	return val
+}
+
+func setEnv(_ *Interpreter, args []interface{}) interface{} {
+	name := stringArg("set_env", args, 0)
+	if err := os.Setenv(name, stringify(args[1])); err != nil {
+		panic(&InterpreterError{Message: fmt.Sprintf("set_env(\"%s\"): %s", name, err)})
+	}
+	return nil
+}
+
+func exit(_ *Interpreter, args []interface{}) interface{} {
+	if len(args) > 1 {
+		panic(&InterpreterError{Message: fmt.Sprintf("exit() expects at most one argument, got %d", len(args))})
+	}
+
+	code := 0
+	if len(args) == 1 {
+		code = int(numberArg("exit", args, 0))
+	}
+	panic(&ExitError{Code: code})
+}
@@ -2,17 +2,47 @@ package jazz
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 )
 
 type ParserError struct {
+	Line    int
+	Column  int
 	Message string
 }
 
 func (err *ParserError) Error() string {
-	return err.Message
+	return fmt.Sprintf("[line %d] error: %s", err.Line, err.Message)
+}
+
+type ParserOpt func(*ParserCfg)
+
+type ParserCfg struct {
+	stderr  io.Writer
+	recover bool
+}
+
+// WithErrOutput sets where the parser reports syntax errors.
+func WithErrOutput(w io.Writer) ParserOpt {
+	return func(cfg *ParserCfg) {
+		cfg.stderr = w
+	}
+}
+
+// WithRecovery makes the parser stand in ErrorStmt and ErrorExpr nodes for
+// code it cannot parse and carry on inside blocks and argument lists, so that
+// tools get a best-effort tree. Parse then returns every error as an
+// ErrorList.
+func WithRecovery(recover bool) ParserOpt {
+	return func(cfg *ParserCfg) {
+		cfg.recover = recover
+	}
 }
 
 type Parser struct {
+	cfg      *ParserCfg
 	Errors   []error
 	Tokens   []*Token
 	Position struct {
@@ -20,8 +50,15 @@ type Parser struct {
 	}
 }
 
-func NewParser(tokens []*Token) *Parser {
-	return &Parser{Tokens: tokens, Errors: []error{}}
+func NewParser(tokens []*Token, options ...ParserOpt) *Parser {
+	cfg := &ParserCfg{
+		stderr: os.Stderr,
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return &Parser{cfg: cfg, Tokens: tokens, Errors: []error{}}
 }
 
 func (p *Parser) HasErrors() bool {
@@ -29,33 +66,136 @@ func (p *Parser) HasErrors() bool {
 }
 
 func (p *Parser) ReportErr(line int, msg string) {
-	errMsg := fmt.Sprintf("[line %d] error: %s", line, msg)
-	fmt.Println(errMsg)
-	p.Errors = append(p.Errors, &ParserError{Message: errMsg})
+	p.report(&ParserError{Line: line, Message: msg})
+}
+
+// report prints and records err. Errors raised without a line are placed at
+// the token the parser stopped on.
+func (p *Parser) report(err error) {
+	if perr, ok := err.(*ParserError); ok && perr.Line == 0 {
+		perr.Line = p.peek().Line
+		perr.Column = p.peek().Column
+	}
+	fmt.Fprintln(p.cfg.stderr, err)
+	p.Errors = append(p.Errors, err)
 }
 
 func (p *Parser) Parse() ([]Stmt, error) {
 	stmts := []Stmt{}
 	for !p.isAtEnd() {
+		start := p.Position.Current
 		stmt, err := p.declaration()
+		if err != nil && p.cfg.recover {
+			stmts = append(stmts, p.errorStmt(start, err))
+			continue
+		}
 		if err != nil {
-			fmt.Println(err)
+			p.report(err)
 			p.sync()
 			continue
 		}
 		stmts = append(stmts, stmt)
 	}
 
+	if p.cfg.recover {
+		return stmts, ErrorList(p.Errors).Err()
+	}
 	return stmts, nil
 }
 
+// ParseExpr parses tokens holding a single expression, such as one typed at a
+// debugger prompt.
+func (p *Parser) ParseExpr() (Expr, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isAtEnd() {
+		return nil, &ParserError{Line: p.peek().Line, Column: p.peek().Column, Message: fmt.Sprintf("unexpected '%s' after expression.", p.peek().Lexeme)}
+	}
+	return expr, nil
+}
+
+// errorStmt reports err and skips to the next statement in the enclosing
+// block, returning a placeholder for the skipped tokens.
+func (p *Parser) errorStmt(start int, err error) Stmt {
+	p.report(err)
+	p.syncStmt()
+	if p.Position.Current == start {
+		p.move()
+	}
+	return &ErrorStmt{Tokens: p.Tokens[start:p.Position.Current]}
+}
+
+// syncStmt skips past the ';' ending the current statement, or up to the
+// keyword starting the next one. Nested braces are skipped whole and it stops
+// before a '}' closing the enclosing block.
+func (p *Parser) syncStmt() {
+	depth := 0
+	for !p.isAtEnd() {
+		switch p.peek().TokenType {
+		case TokenTypeLeftBrace:
+			depth++
+		case TokenTypeRightBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case TokenTypeSemicolon:
+			if depth == 0 {
+				p.move()
+				return
+			}
+		case TokenTypeBreak, TokenTypeConst, TokenTypeContinue, TokenTypeDebugger, TokenTypeFor, TokenTypeFunc, TokenTypeIf,
+			TokenTypeMatch, TokenTypePrint, TokenTypeReturn, TokenTypeVar, TokenTypeWhile:
+			if depth == 0 {
+				return
+			}
+		}
+		p.move()
+	}
+}
+
 func (p *Parser) declaration() (Stmt, error) {
 	if p.match(TokenTypeVar) {
-		return p.varDeclaration()
+		return p.documented(p.previous(), p.varDeclaration)
+	}
+	if p.match(TokenTypeConst) {
+		return p.documented(p.previous(), p.constDeclaration)
 	}
 	return p.stmt()
 }
 
+// documented parses a declaration and attaches the doc comment leading its
+// keyword.
+func (p *Parser) documented(keyword *Token, declaration func() (Stmt, error)) (Stmt, error) {
+	stmt, err := declaration()
+	switch s := stmt.(type) {
+	case *FuncStmt:
+		s.Doc = docComment(keyword)
+	case *VarStmt:
+		s.Doc = docComment(keyword)
+	}
+	return stmt, err
+}
+
+// docComment returns the text of the '///' lines directly above token, with
+// the slashes and one following space removed.
+func docComment(token *Token) string {
+	lines := []string{}
+	line := token.Line
+	for n := len(token.Leading) - 1; n >= 0; n-- {
+		comment := token.Leading[n]
+		if comment.Line != line-1 || !strings.HasPrefix(comment.Lexeme, "///") {
+			break
+		}
+		text := strings.TrimPrefix(comment.Lexeme, "///")
+		lines = append([]string{strings.TrimPrefix(text, " ")}, lines...)
+		line = comment.Line
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (p *Parser) stmt() (Stmt, error) {
 	if p.match(TokenTypeBreak) {
 		return &BreakStmt{Keyword: p.previous()}, p.expectSemicolon("break")
@@ -63,15 +203,23 @@ func (p *Parser) stmt() (Stmt, error) {
 	if p.match(TokenTypeContinue) {
 		return &ContinueStmt{Keyword: p.previous()}, p.expectSemicolon("continue")
 	}
+	if p.match(TokenTypeDebugger) {
+		return &DebuggerStmt{Keyword: p.previous()}, p.expectSemicolon("debugger")
+	}
 	if p.match(TokenTypeFor) {
 		return p.forStmt()
 	}
 	if p.match(TokenTypeFunc) {
-		return p.function("function")
+		return p.documented(p.previous(), func() (Stmt, error) {
+			return p.function("function")
+		})
 	}
 	if p.match(TokenTypeIf) {
 		return p.ifStmt()
 	}
+	if p.match(TokenTypeMatch) {
+		return p.matchStmt()
+	}
 	if p.match(TokenTypePrint) {
 		return p.printStmt()
 	}
@@ -82,12 +230,13 @@ func (p *Parser) stmt() (Stmt, error) {
 		return p.whileStmt()
 	}
 	if p.match(TokenTypeLeftBrace) {
+		brace := p.previous()
 		stmts, err := p.block()
 		if err != nil {
 			return nil, err
 		}
 
-		return &BlockStmt{Stmts: stmts}, nil
+		return &BlockStmt{Brace: brace, Stmts: stmts, End: p.previous()}, nil
 	}
 
 	return p.expressionStmt()
@@ -98,11 +247,18 @@ func (p *Parser) expectSemicolon(keyword string) error {
 	return err
 }
 
+// block parses declarations up to and including the closing brace, which is
+// left as the previous token.
 func (p *Parser) block() ([]Stmt, error) {
 	stmts := make([]Stmt, 0)
 
 	for !p.check(TokenTypeRightBrace) && !p.isAtEnd() {
+		start := p.Position.Current
 		stmt, err := p.declaration()
+		if err != nil && p.cfg.recover {
+			stmts = append(stmts, p.errorStmt(start, err))
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -125,24 +281,24 @@ func (p *Parser) function(kind string) (Stmt, error) {
 		return nil, err
 	}
 
-	params := []*Token{}
+	params := []Pattern{}
 	if !p.check(TokenTypeRightParen) {
-		token, err := p.consume(TokenTypeIdentifier, "expected parameter name")
+		param, err := p.declarationPattern("expected parameter name")
 		if err != nil {
 			return nil, err
 		}
-		params = append(params, token)
+		params = append(params, param)
 		for p.match(TokenTypeComma) {
-			token, err := p.consume(TokenTypeIdentifier, "expected parameter name")
+			param, err := p.declarationPattern("expected parameter name")
 			if err != nil {
 				return nil, err
 			}
 
 			if len(params) >= 255 {
-				ReportErr(p.peek().Line, "cannot have more than 255 parameters.")
+				p.ReportErr(p.peek().Line, "cannot have more than 255 parameters.")
 			}
 
-			params = append(params, token)
+			params = append(params, param)
 		}
 	}
 	_, err = p.consume(TokenTypeRightParen, "expected ')' after parameters.")
@@ -160,15 +316,23 @@ func (p *Parser) function(kind string) (Stmt, error) {
 		return nil, err
 	}
 
-	return &FuncStmt{Name: name, Params: params, Body: block}, nil
+	return &FuncStmt{Name: name, Params: params, Body: block, End: p.previous()}, nil
 }
 
 func (p *Parser) forStmt() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(TokenTypeLeftParen, "expected '(' after for.")
 	if err != nil {
 		return nil, err
 	}
 
+	if p.check(TokenTypeVar) || p.check(TokenTypeConst) {
+		stmt, ok, err := p.forInStmt(keyword)
+		if ok || err != nil {
+			return stmt, err
+		}
+	}
+
 	var initializer Stmt
 	if p.match(TokenTypeSemicolon) {
 		initializer = nil
@@ -197,7 +361,7 @@ func (p *Parser) forStmt() (Stmt, error) {
 	}
 
 	var increment Expr
-	if !p.match(TokenTypeRightParen) {
+	if !p.check(TokenTypeRightParen) {
 		increment, err = p.expression()
 		if err != nil {
 			return nil, err
@@ -217,7 +381,7 @@ func (p *Parser) forStmt() (Stmt, error) {
 		condition = &LiteralExpr{Val: true}
 	}
 
-	var whileBody Stmt = &WhileStmt{Condition: condition, Body: body, Increment: increment}
+	var whileBody Stmt = &WhileStmt{Keyword: keyword, Condition: condition, Body: body, Increment: increment}
 
 	if initializer != nil {
 		whileBody = &BlockStmt{Stmts: []Stmt{initializer, whileBody}}
@@ -226,6 +390,40 @@ func (p *Parser) forStmt() (Stmt, error) {
 	return whileBody, nil
 }
 
+// forInStmt parses the rest of a 'for (let pattern in iterable)' loop. It
+// reports ok=false, leaving the position untouched, when the loop head turns
+// out to be a regular for-loop initializer.
+func (p *Parser) forInStmt(keyword *Token) (Stmt, bool, error) {
+	start := p.Position.Current
+	isConst := p.match(TokenTypeConst)
+	if !isConst {
+		p.move()
+	}
+
+	pattern, err := p.declarationPattern("expected variable name.")
+	if err != nil || !p.match(TokenTypeIn) {
+		p.Position.Current = start
+		return nil, false, nil
+	}
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, true, err
+	}
+
+	_, err = p.consume(TokenTypeRightParen, "expected ')' after for-in clause.")
+	if err != nil {
+		return nil, true, err
+	}
+
+	body, err := p.stmt()
+	if err != nil {
+		return nil, true, err
+	}
+
+	return &ForInStmt{Keyword: keyword, Pattern: pattern, Iterable: iterable, Body: body, Const: isConst}, true, nil
+}
+
 func (p *Parser) call() (Expr, error) {
 	expr, err := p.primary()
 	if err != nil {
@@ -259,20 +457,20 @@ func (p *Parser) call() (Expr, error) {
 func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	args := []Expr{}
 	if !p.check(TokenTypeRightParen) {
-		expr, err := p.expression()
+		expr, err := p.argument()
 		if err != nil {
 			return nil, err
 		}
 
 		args = append(args, expr)
 		for p.match(TokenTypeComma) {
-			expr, err := p.expression()
+			expr, err := p.argument()
 			if err != nil {
 				return nil, err
 			}
 			args = append(args, expr)
 			if len(args) >= 255 {
-				ReportErr(p.peek().Line, "cannot have more than 255 arguments.")
+				p.ReportErr(p.peek().Line, "cannot have more than 255 arguments.")
 			}
 		}
 	}
@@ -285,7 +483,36 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	return &CallExpr{Callee: callee, Paren: paren, Args: args}, nil
 }
 
+// argument parses a call argument. In recovery mode a malformed argument is
+// reported and skipped up to the next ',' or ')'.
+func (p *Parser) argument() (Expr, error) {
+	start := p.Position.Current
+	expr, err := p.expression()
+	if err == nil || !p.cfg.recover {
+		return expr, err
+	}
+
+	p.report(err)
+	depth := 0
+	for !p.isAtEnd() && !p.check(TokenTypeSemicolon) {
+		t := p.peek().TokenType
+		if depth == 0 && (t == TokenTypeComma || t == TokenTypeRightParen) {
+			break
+		}
+		switch t {
+		case TokenTypeLeftParen, TokenTypeLeftBracket, TokenTypeLeftBrace:
+			depth++
+		case TokenTypeRightParen, TokenTypeRightBracket, TokenTypeRightBrace:
+			depth--
+		}
+		p.move()
+	}
+
+	return &ErrorExpr{Tokens: p.Tokens[start:p.Position.Current]}, nil
+}
+
 func (p *Parser) ifStmt() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(TokenTypeLeftParen, "expected '(' after if.")
 	if err != nil {
 		return nil, err
@@ -314,7 +541,251 @@ func (p *Parser) ifStmt() (Stmt, error) {
 		}
 	}
 
-	return &IfStmt{Condition: condition, ThenStmt: thenStmt, ElseStmt: elseStmt}, nil
+	return &IfStmt{Keyword: keyword, Condition: condition, ThenStmt: thenStmt, ElseStmt: elseStmt}, nil
+}
+
+func (p *Parser) matchStmt() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(TokenTypeLeftParen, "expected '(' after match.")
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(TokenTypeRightParen, "expected ')' after match value.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(TokenTypeLeftBrace, "expected '{' before match arms.")
+	if err != nil {
+		return nil, err
+	}
+
+	arms := []*MatchArm{}
+	for !p.check(TokenTypeRightBrace) && !p.isAtEnd() {
+		arm, err := p.matchArm()
+		if err != nil {
+			return nil, err
+		}
+		arms = append(arms, arm)
+	}
+
+	end, err := p.consume(TokenTypeRightBrace, "expected '}' after match arms.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &MatchStmt{Keyword: keyword, Subject: subject, Arms: arms, End: end}, nil
+}
+
+func (p *Parser) matchArm() (*MatchArm, error) {
+	pattern, err := p.pattern()
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := []Pattern{pattern}
+	for p.match(TokenTypeComma) {
+		pattern, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	arrow, err := p.consume(TokenTypeArrow, "expected '=>' after match pattern.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.stmt()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MatchArm{Patterns: patterns, Arrow: arrow, Body: body}, nil
+}
+
+func (p *Parser) pattern() (Pattern, error) {
+	if p.match(TokenTypeLeftBracket) {
+		bracket := p.previous()
+		elements := []Pattern{}
+		var rest *Token
+		if !p.check(TokenTypeRightBracket) {
+			for {
+				if p.match(TokenTypeEllipsis) {
+					name, err := p.consume(TokenTypeIdentifier, "expected name after '...'.")
+					if err != nil {
+						return nil, err
+					}
+					rest = name
+					break
+				}
+				el, err := p.pattern()
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, el)
+				if !p.match(TokenTypeComma) {
+					break
+				}
+			}
+		}
+		_, err := p.consume(TokenTypeRightBracket, "expected ']' after array pattern.")
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayPattern{Bracket: bracket, Elements: elements, Rest: rest}, nil
+	}
+	if p.match(TokenTypeLeftBrace) {
+		brace := p.previous()
+		entries := []*MapPatternEntry{}
+		if !p.check(TokenTypeRightBrace) {
+			for {
+				entry, err := p.mapPatternEntry()
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, entry)
+				if !p.match(TokenTypeComma) {
+					break
+				}
+			}
+		}
+		_, err := p.consume(TokenTypeRightBrace, "expected '}' after map pattern.")
+		if err != nil {
+			return nil, err
+		}
+		return &MapPattern{Brace: brace, Entries: entries}, nil
+	}
+	if p.match(TokenTypeIdentifier) {
+		name := p.previous()
+		if name.Lexeme == "_" {
+			return &WildcardPattern{Token: name}, nil
+		}
+		return &BindingPattern{Name: name}, nil
+	}
+	if p.match(TokenTypeFalse) {
+		return &LiteralPattern{Token: p.previous(), Val: false}, nil
+	}
+	if p.match(TokenTypeTrue) {
+		return &LiteralPattern{Token: p.previous(), Val: true}, nil
+	}
+	if p.match(TokenTypeNil) {
+		return &LiteralPattern{Token: p.previous(), Val: nil}, nil
+	}
+	if p.match(TokenTypeString) {
+		return &LiteralPattern{Token: p.previous(), Val: p.previous().Literal}, nil
+	}
+	if p.check(TokenTypeNumber) || p.check(TokenTypeMinus) {
+		token := p.peek()
+		low, err := p.patternNumber()
+		if err != nil {
+			return nil, err
+		}
+		if p.match(TokenTypeDotDot) {
+			high, err := p.patternNumber()
+			if err != nil {
+				return nil, err
+			}
+			return &RangePattern{Token: token, Low: low, High: high}, nil
+		}
+		return &LiteralPattern{Token: token, Val: low}, nil
+	}
+
+	return nil, &ParserError{Message: "expected a pattern."}
+}
+
+// mapPatternEntry parses 'key' or 'key: pattern', where the shorthand binds
+// the value to a variable named after the key.
+func (p *Parser) mapPatternEntry() (*MapPatternEntry, error) {
+	if p.match(TokenTypeString) {
+		key := p.previous()
+		_, err := p.consume(TokenTypeColon, "expected ':' after string key in map pattern.")
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		return &MapPatternEntry{Key: key, Pattern: pattern}, nil
+	}
+
+	key, err := p.consume(TokenTypeIdentifier, "expected key in map pattern.")
+	if err != nil {
+		return nil, err
+	}
+	if p.match(TokenTypeColon) {
+		pattern, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		return &MapPatternEntry{Key: key, Pattern: pattern}, nil
+	}
+
+	return &MapPatternEntry{Key: key, Pattern: &BindingPattern{Name: key}}, nil
+}
+
+// declarationPattern parses the target of a let, const, parameter or for-in
+// binding: a name or an array or map pattern made only of names.
+func (p *Parser) declarationPattern(message string) (Pattern, error) {
+	if !p.check(TokenTypeIdentifier) && !p.check(TokenTypeLeftBracket) && !p.check(TokenTypeLeftBrace) {
+		return nil, &ParserError{Message: message}
+	}
+
+	pattern, err := p.pattern()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isDeclarationPattern(pattern) {
+		return nil, &ParserError{Message: "only names can be bound in a declaration pattern."}
+	}
+
+	return pattern, nil
+}
+
+func isDeclarationPattern(pattern Pattern) bool {
+	switch pat := pattern.(type) {
+	case *BindingPattern, *WildcardPattern:
+		return true
+	case *ArrayPattern:
+		for _, el := range pat.Elements {
+			if !isDeclarationPattern(el) {
+				return false
+			}
+		}
+		return true
+	case *MapPattern:
+		for _, entry := range pat.Entries {
+			if !isDeclarationPattern(entry.Pattern) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func (p *Parser) patternNumber() (float64, error) {
+	sign := 1.0
+	if p.match(TokenTypeMinus) {
+		sign = -1.0
+	}
+
+	token, err := p.consume(TokenTypeNumber, "expected a number in pattern.")
+	if err != nil {
+		return 0, err
+	}
+
+	return sign * token.Literal.(float64), nil
 }
 
 func (p *Parser) returnStmt() (Stmt, error) {
@@ -338,6 +809,7 @@ func (p *Parser) returnStmt() (Stmt, error) {
 }
 
 func (p *Parser) whileStmt() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(TokenTypeLeftParen, "expected '(' after while.")
 	if err != nil {
 		return nil, err
@@ -358,17 +830,18 @@ func (p *Parser) whileStmt() (Stmt, error) {
 		return nil, err
 	}
 
-	return &WhileStmt{Condition: condition, Body: body}, nil
+	return &WhileStmt{Keyword: keyword, Condition: condition, Body: body}, nil
 }
 
 func (p *Parser) printStmt() (Stmt, error) {
+	keyword := p.previous()
 	val, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
 	_, err = p.consume(TokenTypeSemicolon, "expected ';' after value.")
-	return &PrintStmt{Expr: val}, err
+	return &PrintStmt{Keyword: keyword, Expr: val}, err
 }
 
 func (p *Parser) expressionStmt() (Stmt, error) {
@@ -382,6 +855,10 @@ func (p *Parser) expressionStmt() (Stmt, error) {
 }
 
 func (p *Parser) varDeclaration() (Stmt, error) {
+	if p.check(TokenTypeLeftBracket) || p.check(TokenTypeLeftBrace) {
+		return p.destructuringDeclaration(false)
+	}
+
 	name, err := p.consume(TokenTypeIdentifier, "expected variable name.")
 	if err != nil {
 		return nil, err
@@ -399,6 +876,50 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 	return &VarStmt{Name: name, Initializer: initializer}, err
 }
 
+func (p *Parser) constDeclaration() (Stmt, error) {
+	if p.check(TokenTypeLeftBracket) || p.check(TokenTypeLeftBrace) {
+		return p.destructuringDeclaration(true)
+	}
+
+	name, err := p.consume(TokenTypeIdentifier, "expected constant name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(TokenTypeEq, "expected '=' after constant name.")
+	if err != nil {
+		return nil, err
+	}
+
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(TokenTypeSemicolon, "expected ';' after expression.")
+	return &VarStmt{Name: name, Initializer: initializer, Const: true}, err
+}
+
+func (p *Parser) destructuringDeclaration(isConst bool) (Stmt, error) {
+	pattern, err := p.declarationPattern("expected variable name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(TokenTypeEq, "expected '=' after destructuring pattern.")
+	if err != nil {
+		return nil, err
+	}
+
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(TokenTypeSemicolon, "expected ';' after expression.")
+	return &VarStmt{Pattern: pattern, Initializer: initializer, Const: isConst}, err
+}
+
 func (p *Parser) sync() {
 	p.move()
 	for !p.isAtEnd() {
@@ -408,14 +929,20 @@ func (p *Parser) sync() {
 		switch p.peek().TokenType {
 		case TokenTypeBreak:
 			fallthrough
+		case TokenTypeConst:
+			fallthrough
 		case TokenTypeContinue:
 			fallthrough
+		case TokenTypeDebugger:
+			fallthrough
 		case TokenTypeFor:
 			fallthrough
 		case TokenTypeFunc:
 			fallthrough
 		case TokenTypeIf:
 			fallthrough
+		case TokenTypeMatch:
+			fallthrough
 		case TokenTypePrint:
 			fallthrough
 		case TokenTypeReturn:
@@ -471,12 +998,13 @@ func (p *Parser) expression() (Expr, error) {
 }
 
 func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.or()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
 
-	if p.match(TokenTypeEq) {
+	if p.match(TokenTypeEq, TokenTypePlusEq, TokenTypeMinusEq, TokenTypeStarEq, TokenTypeSlashEq, TokenTypePercentEq) {
+		operator := p.previous()
 		val, err := p.assignment()
 		if err != nil {
 			return nil, err
@@ -484,13 +1012,54 @@ func (p *Parser) assignment() (Expr, error) {
 
 		switch t := expr.(type) {
 		case *VarExpr:
-			return &AssignExpr{Name: t.Name, Val: val}, nil
+			return &AssignExpr{Name: t.Name, Operator: operator, Val: val}, nil
 		case *IndexGetExpr:
-			return &IndexSetExpr{Object: t.Object, Index: t.Index, Val: val, Bracket: t.Bracket}, nil
+			return &IndexSetExpr{Object: t.Object, Index: t.Index, Val: val, Bracket: t.Bracket, Operator: operator}, nil
 		}
 
-		eq := p.previous()
-		return nil, fmt.Errorf("invalid assignment target: %s", eq.Lexeme)
+		return nil, &ParserError{Line: operator.Line, Message: "invalid assignment target."}
+	}
+
+	return expr, nil
+}
+
+// increment desugars '++' and '--' into an assignment of one to target.
+func (p *Parser) increment(target Expr, operator *Token, postfix bool) (Expr, error) {
+	one := &LiteralExpr{Val: 1.0}
+	switch t := target.(type) {
+	case *VarExpr:
+		return &AssignExpr{Name: t.Name, Operator: operator, Val: one, Postfix: postfix}, nil
+	case *IndexGetExpr:
+		return &IndexSetExpr{Object: t.Object, Index: t.Index, Val: one, Bracket: t.Bracket, Operator: operator, Postfix: postfix}, nil
+	}
+
+	return nil, &ParserError{Message: fmt.Sprintf("invalid %s target.", operator.Lexeme)}
+}
+
+func (p *Parser) conditional() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(TokenTypeQuestion) {
+		question := p.previous()
+		thenExpr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = p.consume(TokenTypeColon, "expected ':' after conditional branch.")
+		if err != nil {
+			return nil, err
+		}
+
+		elseExpr, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ConditionalExpr{Condition: expr, Question: question, Then: thenExpr, Else: elseExpr}, nil
 	}
 
 	return expr, nil
@@ -597,7 +1166,7 @@ func (p *Parser) factor() (Expr, error) {
 		return nil, err
 	}
 
-	for p.match(TokenTypeSlash, TokenTypeStar) {
+	for p.match(TokenTypeSlash, TokenTypeStar, TokenTypePercent) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -620,8 +1189,30 @@ func (p *Parser) unary() (Expr, error) {
 
 		return &UnaryExpr{Operator: operator, Right: right}, nil
 	}
+	if p.match(TokenTypePlusPlus, TokenTypeMinusMinus) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
 
-	return p.call()
+		return p.increment(target, operator, false)
+	}
+
+	return p.postfix()
+}
+
+func (p *Parser) postfix() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(TokenTypePlusPlus, TokenTypeMinusMinus) {
+		return p.increment(expr, p.previous(), true)
+	}
+
+	return expr, nil
 }
 
 func (p *Parser) consume(t TokenType, message string) (*Token, error) {
@@ -658,6 +1249,9 @@ func (p *Parser) primary() (Expr, error) {
 		}
 		return &ArrayExpr{Elements: elements, Bracket: bracket}, nil
 	}
+	if p.match(TokenTypeLeftBrace) {
+		return p.mapLiteral()
+	}
 	if p.match(TokenTypeFalse) {
 		return &LiteralExpr{Val: false}, nil
 	}
@@ -685,3 +1279,40 @@ func (p *Parser) primary() (Expr, error) {
 
 	return nil, &ParserError{Message: "expected an expression."}
 }
+
+func (p *Parser) mapLiteral() (Expr, error) {
+	brace := p.previous()
+	keys := []*Token{}
+	vals := []Expr{}
+	if !p.check(TokenTypeRightBrace) {
+		for {
+			if !p.match(TokenTypeIdentifier, TokenTypeString) {
+				return nil, &ParserError{Message: "expected key in map literal."}
+			}
+			key := p.previous()
+
+			_, err := p.consume(TokenTypeColon, "expected ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			vals = append(vals, val)
+			if !p.match(TokenTypeComma) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(TokenTypeRightBrace, "expected '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &MapExpr{Brace: brace, Keys: keys, Vals: vals}, nil
+}
@@ -0,0 +1,169 @@
+package jazz
+
+import "fmt"
+
+// Pattern is the left-hand side of a match arm or a destructuring
+// declaration.
+type Pattern interface {
+	pattern()
+}
+
+// LiteralPattern matches a value equal to a number, string, bool or nil literal.
+type LiteralPattern struct {
+	Token *Token
+	Val   interface{}
+}
+
+// RangePattern matches a number between Low and High, both inclusive.
+type RangePattern struct {
+	Token *Token
+	Low   float64
+	High  float64
+}
+
+// ArrayPattern matches an array element-wise. When Rest is set the array may
+// be longer than Elements and the remaining elements are bound to Rest.
+type ArrayPattern struct {
+	Bracket  *Token
+	Elements []Pattern
+	Rest     *Token
+}
+
+// MapPattern matches a map holding every key in Entries.
+type MapPattern struct {
+	Brace   *Token
+	Entries []*MapPatternEntry
+}
+
+type MapPatternEntry struct {
+	Key     *Token
+	Pattern Pattern
+}
+
+// BindingPattern matches any value and binds it to Name.
+type BindingPattern struct {
+	Name *Token
+}
+
+// WildcardPattern, written '_', matches any value without binding it.
+type WildcardPattern struct {
+	Token *Token
+}
+
+func (p *LiteralPattern) pattern()  {}
+func (p *RangePattern) pattern()    {}
+func (p *ArrayPattern) pattern()    {}
+func (p *MapPattern) pattern()      {}
+func (p *BindingPattern) pattern()  {}
+func (p *WildcardPattern) pattern() {}
+
+// patternNames returns the tokens of all names bound by a pattern.
+func patternNames(pattern Pattern) []*Token {
+	switch p := pattern.(type) {
+	case *BindingPattern:
+		return []*Token{p.Name}
+	case *ArrayPattern:
+		names := []*Token{}
+		for _, el := range p.Elements {
+			names = append(names, patternNames(el)...)
+		}
+		if p.Rest != nil {
+			names = append(names, p.Rest)
+		}
+		return names
+	case *MapPattern:
+		names := []*Token{}
+		for _, entry := range p.Entries {
+			names = append(names, patternNames(entry.Pattern)...)
+		}
+		return names
+	}
+
+	return nil
+}
+
+// isIrrefutable reports whether a pattern matches every value.
+func isIrrefutable(pattern Pattern) bool {
+	switch pattern.(type) {
+	case *BindingPattern, *WildcardPattern:
+		return true
+	}
+
+	return false
+}
+
+// bindPattern destructures val according to pattern, collecting the values of
+// bound names in bindings. It returns an error describing the first place
+// where the shape of val does not fit the pattern.
+func bindPattern(pattern Pattern, val interface{}, bindings map[string]interface{}) error {
+	switch p := pattern.(type) {
+	case *LiteralPattern:
+		if !isEqual(val, p.Val) {
+			return &InterpreterError{Message: fmt.Sprintf("expected %v but got %v", p.Val, val)}
+		}
+	case *RangePattern:
+		f, ok := val.(float64)
+		if !ok || f < p.Low || f > p.High {
+			return &InterpreterError{Message: fmt.Sprintf("expected a number in %v..%v but got %v", p.Low, p.High, val)}
+		}
+	case *ArrayPattern:
+		arr, ok := val.(*JazzArray)
+		if !ok {
+			return &InterpreterError{Message: fmt.Sprintf("cannot destructure %s as an array", typeName(val))}
+		}
+		if p.Rest == nil && len(arr.Elements) != len(p.Elements) {
+			return &InterpreterError{Message: fmt.Sprintf("expected an array of %d elements but got %d", len(p.Elements), len(arr.Elements))}
+		}
+		if len(arr.Elements) < len(p.Elements) {
+			return &InterpreterError{Message: fmt.Sprintf("expected an array of at least %d elements but got %d", len(p.Elements), len(arr.Elements))}
+		}
+		for i, el := range p.Elements {
+			if err := bindPattern(el, arr.Elements[i], bindings); err != nil {
+				return err
+			}
+		}
+		if p.Rest != nil {
+			rest := make([]interface{}, len(arr.Elements)-len(p.Elements))
+			copy(rest, arr.Elements[len(p.Elements):])
+			bindings[p.Rest.Lexeme] = NewJazzArray(rest)
+		}
+	case *MapPattern:
+		m, ok := val.(*JazzMap)
+		if !ok {
+			return &InterpreterError{Message: fmt.Sprintf("cannot destructure %s as a map", typeName(val))}
+		}
+		for _, entry := range p.Entries {
+			key := mapKey(entry.Key)
+			v, ok := m.Get(key)
+			if !ok {
+				return &InterpreterError{Message: fmt.Sprintf("map has no key '%s'", key)}
+			}
+			if err := bindPattern(entry.Pattern, v, bindings); err != nil {
+				return err
+			}
+		}
+	case *BindingPattern:
+		bindings[p.Name.Lexeme] = val
+	}
+
+	return nil
+}
+
+// definePattern destructures val according to pattern and defines every bound
+// name in env.
+func definePattern(env *Env, pattern Pattern, val interface{}, isConst bool) error {
+	bindings := map[string]interface{}{}
+	if err := bindPattern(pattern, val, bindings); err != nil {
+		return err
+	}
+
+	for _, name := range patternNames(pattern) {
+		if isConst {
+			env.DefineConst(name.Lexeme, bindings[name.Lexeme])
+		} else {
+			env.Define(name.Lexeme, bindings[name.Lexeme])
+		}
+	}
+
+	return nil
+}
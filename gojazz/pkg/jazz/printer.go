@@ -0,0 +1,772 @@
+package jazz
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+const printerIndent = "    "
+
+// Format parses source and prints it back in canonical form. Formatting
+// formatted source returns it unchanged.
+func Format(source string) (string, error) {
+	tokens, err := NewScanner(source, WithTrivia(true)).ScanTokens()
+	if err != nil {
+		return "", err
+	}
+
+	parser := NewParser(tokens, WithErrOutput(io.Discard))
+	stmts, _ := parser.Parse()
+	if parser.HasErrors() {
+		return "", ErrorList(parser.Errors)
+	}
+
+	return NewPrinter(source, tokens).Print(stmts), nil
+}
+
+// Printer renders statements as Jazz source with four-space indentation and
+// opening braces on the same line. It walks the tokens the statements were
+// parsed from alongside them, printing the comments scanned WithTrivia where
+// the token they lead is printed: on lines of their own before it, at the end
+// of the line before it, or inline just before it.
+type Printer struct {
+	lines   []string
+	scanned []*Token
+	next    int // index in scanned of the first token not printed yet
+	done    int // number of the comments leading scanned[next] printed already
+	buf     strings.Builder
+	indent  int
+}
+
+// NewPrinter returns a printer for statements parsed from tokens, which were
+// scanned from source WithTrivia. Without tokens no comments are printed.
+func NewPrinter(source string, tokens []*Token) *Printer {
+	return &Printer{lines: strings.Split(source, "\n"), scanned: tokens}
+}
+
+func (p *Printer) Print(stmts []Stmt) string {
+	p.list(stmts)
+	for p.next < len(p.scanned) {
+		p.lineComments(false)
+		p.advance()
+	}
+	if p.buf.Len() > 0 {
+		p.buf.WriteString("\n")
+	}
+	return p.buf.String()
+}
+
+// list prints stmts one per line, followed by the comments before the token
+// that ends them.
+func (p *Printer) list(stmts []Stmt) {
+	first := true
+	for _, stmt := range stmts {
+		line := StmtLine(stmt)
+		first = p.lineComments(first)
+		p.newline(line, first)
+		first = false
+		stmt.Accept(p)
+	}
+	p.lineComments(first)
+}
+
+// lineComments prints the comments leading the next token that end the line
+// of the token before it, or that have lines of their own. Comments on the
+// line of the token itself are left for advance to print inline. It reports
+// whether the next line is still the first in its block.
+func (p *Printer) lineComments(first bool) bool {
+	for _, comment := range p.pending() {
+		next := p.scanned[p.next]
+		switch {
+		case p.next > 0 && comment.Line == p.scanned[p.next-1].Line:
+			p.buf.WriteString(" ")
+		case next.TokenType != TokenTypeEOF && endLine(comment) == next.Line:
+			return first
+		default:
+			p.newline(comment.Line, first)
+			first = false
+		}
+		p.buf.WriteString(comment.Lexeme)
+		p.done++
+	}
+	return first
+}
+
+// pending returns the comments leading the next token not printed yet.
+func (p *Printer) pending() []*Token {
+	if p.next >= len(p.scanned) {
+		return nil
+	}
+	return p.scanned[p.next].Leading[p.done:]
+}
+
+// advance moves past the next token, printing the comments still leading it
+// in the middle of the line. A line comment breaks the line, continuing it one
+// level deeper.
+func (p *Printer) advance() {
+	for _, comment := range p.pending() {
+		lineComment := !strings.HasPrefix(comment.Lexeme, "/*")
+		if lineComment || !p.afterSpace() {
+			p.space()
+		}
+		p.buf.WriteString(comment.Lexeme)
+		switch {
+		case lineComment:
+			p.indent++
+			p.newline(0, true)
+			p.indent--
+		case !strings.Contains(",;:)]", p.scanned[p.next].Lexeme):
+			p.buf.WriteString(" ")
+		}
+	}
+	p.next++
+	p.done = 0
+}
+
+// skipTo advances past token, if it is one of the tokens still to print.
+func (p *Printer) skipTo(token *Token) {
+	for n := p.next; n < len(p.scanned); n++ {
+		if p.scanned[n] == token {
+			for p.next <= n {
+				p.advance()
+			}
+			return
+		}
+	}
+}
+
+// afterSpace reports whether the printed code ends in whitespace or an
+// opening bracket, after which a comment needs no space.
+func (p *Printer) afterSpace() bool {
+	s := p.buf.String()
+	return s == "" || strings.ContainsAny(s[len(s)-1:], " \n([{")
+}
+
+func (p *Printer) space() {
+	s := p.buf.String()
+	if s != "" && !strings.ContainsAny(s[len(s)-1:], " \n") {
+		p.buf.WriteString(" ")
+	}
+}
+
+// newline starts a new line at the current indentation, keeping a single
+// blank line where the source had one or more before line.
+func (p *Printer) newline(line int, first bool) {
+	if !first && p.blankBefore(line) {
+		p.buf.WriteString("\n")
+	}
+	if p.buf.Len() > 0 {
+		p.buf.WriteString("\n")
+	}
+	p.buf.WriteString(strings.Repeat(printerIndent, p.indent))
+}
+
+func (p *Printer) blankBefore(line int) bool {
+	return line >= 2 && line-2 < len(p.lines) && strings.TrimSpace(p.lines[line-2]) == ""
+}
+
+// write prints punctuation, keywords and spaces, advancing past each token
+// of the source that s spells out.
+func (p *Printer) write(s string) {
+	for _, word := range strings.SplitAfter(s, " ") {
+		lexeme := strings.TrimSuffix(word, " ")
+		if lexeme != "" && p.next < len(p.scanned) && p.scanned[p.next].Lexeme == lexeme {
+			p.advance()
+		}
+		p.buf.WriteString(word)
+	}
+}
+
+// literal prints a literal value, advancing past the source token it was
+// parsed from. Negative numbers come from patterns, where the minus is a
+// token of its own.
+func (p *Printer) literal(val interface{}) {
+	s := literal(val)
+	if strings.HasPrefix(s, "-") {
+		p.write("-")
+		s = s[1:]
+	}
+	if p.next < len(p.scanned) {
+		switch p.scanned[p.next].TokenType {
+		case TokenTypeNumber, TokenTypeString, TokenTypeTrue, TokenTypeFalse, TokenTypeNil:
+			p.advance()
+		}
+	}
+	p.buf.WriteString(s)
+}
+
+func (p *Printer) token(token *Token) {
+	p.skipTo(token)
+	p.buf.WriteString(token.Lexeme)
+}
+
+func (p *Printer) expr(expr Expr) {
+	expr.Accept(p)
+}
+
+func (p *Printer) exprs(exprs []Expr) {
+	for n, expr := range exprs {
+		if n > 0 {
+			p.write(", ")
+		}
+		p.expr(expr)
+	}
+}
+
+func (p *Printer) block(stmts []Stmt, end *Token) {
+	p.write("{")
+	size := p.buf.Len()
+	p.indent++
+	p.list(stmts)
+	p.indent--
+	if p.buf.Len() > size {
+		p.newline(0, true)
+	}
+	p.write("}")
+}
+
+// body prints the body of an if, loop or match arm: a block on the same line,
+// or a single statement after a space.
+func (p *Printer) body(stmt Stmt) {
+	p.write(" ")
+	stmt.Accept(p)
+}
+
+func (p *Printer) pattern(pattern Pattern) {
+	switch pat := pattern.(type) {
+	case *LiteralPattern:
+		p.skipTo(pat.Token)
+		p.literal(pat.Val)
+	case *RangePattern:
+		p.skipTo(pat.Token)
+		p.literal(pat.Low)
+		p.write("..")
+		p.literal(pat.High)
+	case *ArrayPattern:
+		p.write("[")
+		for n, el := range pat.Elements {
+			if n > 0 {
+				p.write(", ")
+			}
+			p.pattern(el)
+		}
+		if pat.Rest != nil {
+			if len(pat.Elements) > 0 {
+				p.write(", ")
+			}
+			p.write("...")
+			p.token(pat.Rest)
+		}
+		p.write("]")
+	case *MapPattern:
+		p.write("{")
+		for n, entry := range pat.Entries {
+			if n > 0 {
+				p.write(", ")
+			}
+			p.token(entry.Key)
+			if binding, ok := entry.Pattern.(*BindingPattern); ok && binding.Name == entry.Key {
+				continue
+			}
+			p.write(": ")
+			p.pattern(entry.Pattern)
+		}
+		p.write("}")
+	case *BindingPattern:
+		p.token(pat.Name)
+	case *WildcardPattern:
+		p.token(pat.Token)
+	}
+}
+
+func (p *Printer) patterns(patterns []Pattern) {
+	for n, pattern := range patterns {
+		if n > 0 {
+			p.write(", ")
+		}
+		p.pattern(pattern)
+	}
+}
+
+// forLoop re-sugars a for-loop that the parser desugared into a while loop,
+// wrapped in a block when it has an initializer.
+func (p *Printer) forLoop(initializer Stmt, loop *WhileStmt) {
+	p.token(loop.Keyword)
+	p.write(" (")
+	if initializer != nil {
+		initializer.Accept(p)
+	} else {
+		p.write(";")
+	}
+	p.write(" ")
+	p.expr(loop.Condition)
+	p.write(";")
+	if loop.Increment != nil {
+		p.write(" ")
+		p.expr(loop.Increment)
+	}
+	p.write(")")
+	p.body(loop.Body)
+}
+
+func (p *Printer) VisitBlockStmt(stmt *BlockStmt) (interface{}, error) {
+	if stmt.Brace == nil && len(stmt.Stmts) == 2 {
+		if loop, ok := stmt.Stmts[1].(*WhileStmt); ok {
+			p.forLoop(stmt.Stmts[0], loop)
+			return nil, nil
+		}
+	}
+
+	p.block(stmt.Stmts, stmt.End)
+	return nil, nil
+}
+
+func (p *Printer) VisitBreakStmt(stmt *BreakStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitContinueStmt(stmt *ContinueStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitDebuggerStmt(stmt *DebuggerStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitErrorStmt(stmt *ErrorStmt) (interface{}, error) {
+	p.tokens(stmt.Tokens)
+	return nil, nil
+}
+
+func (p *Printer) VisitErrorExpr(expr *ErrorExpr) (interface{}, error) {
+	p.tokens(expr.Tokens)
+	return nil, nil
+}
+
+// tokens prints code that could not be parsed as it was scanned, separated by
+// single spaces.
+func (p *Printer) tokens(tokens []*Token) {
+	for n, token := range tokens {
+		if n > 0 {
+			p.write(" ")
+		}
+		p.token(token)
+	}
+}
+
+func (p *Printer) VisitExprStmt(stmt *ExprStmt) (interface{}, error) {
+	p.expr(stmt.Expr)
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitForInStmt(stmt *ForInStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	if stmt.Const {
+		p.write(" (const ")
+	} else {
+		p.write(" (let ")
+	}
+	p.pattern(stmt.Pattern)
+	p.write(" in ")
+	p.expr(stmt.Iterable)
+	p.write(")")
+	p.body(stmt.Body)
+	return nil, nil
+}
+
+func (p *Printer) VisitFuncStmt(stmt *FuncStmt) (interface{}, error) {
+	p.write("fn ")
+	p.token(stmt.Name)
+	p.write("(")
+	p.patterns(stmt.Params)
+	p.write(") ")
+	p.block(stmt.Body, stmt.End)
+	return nil, nil
+}
+
+func (p *Printer) VisitIfStmt(stmt *IfStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	p.write(" (")
+	p.expr(stmt.Condition)
+	p.write(")")
+	p.body(stmt.ThenStmt)
+	if stmt.ElseStmt != nil {
+		if len(p.pending()) > 0 {
+			// Comments before 'else' end the line of the then branch.
+			p.lineComments(false)
+			p.newline(0, true)
+			p.write("else")
+		} else {
+			p.write(" else")
+		}
+		p.body(stmt.ElseStmt)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitMatchStmt(stmt *MatchStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	p.write(" (")
+	p.expr(stmt.Subject)
+	p.write(") {")
+	p.indent++
+	first := true
+	for _, arm := range stmt.Arms {
+		line := patternLine(arm.Patterns[0])
+		first = p.lineComments(first)
+		p.newline(line, first)
+		first = false
+		p.patterns(arm.Patterns)
+		p.write(" =>")
+		p.body(arm.Body)
+	}
+	p.lineComments(first)
+	p.indent--
+	p.newline(0, true)
+	p.write("}")
+	return nil, nil
+}
+
+func (p *Printer) VisitPrintStmt(stmt *PrintStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	p.write(" ")
+	p.expr(stmt.Expr)
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitReturnStmt(stmt *ReturnStmt) (interface{}, error) {
+	p.token(stmt.Keyword)
+	if stmt.Val != nil {
+		p.write(" ")
+		p.expr(stmt.Val)
+	}
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitVarStmt(stmt *VarStmt) (interface{}, error) {
+	if stmt.Const {
+		p.write("const ")
+	} else {
+		p.write("let ")
+	}
+	if stmt.Pattern != nil {
+		p.pattern(stmt.Pattern)
+	} else {
+		p.token(stmt.Name)
+	}
+	if stmt.Initializer != nil {
+		p.write(" = ")
+		p.expr(stmt.Initializer)
+	}
+	p.write(";")
+	return nil, nil
+}
+
+func (p *Printer) VisitWhileStmt(stmt *WhileStmt) (interface{}, error) {
+	if stmt.Keyword != nil && stmt.Keyword.TokenType == TokenTypeFor {
+		p.forLoop(nil, stmt)
+		return nil, nil
+	}
+
+	p.write("while (")
+	p.expr(stmt.Condition)
+	p.write(")")
+	p.body(stmt.Body)
+	return nil, nil
+}
+
+func (p *Printer) VisitArrayExpr(expr *ArrayExpr) (interface{}, error) {
+	p.write("[")
+	p.exprs(expr.Elements)
+	p.write("]")
+	return nil, nil
+}
+
+func (p *Printer) VisitAssignExpr(expr *AssignExpr) (interface{}, error) {
+	switch expr.Operator.TokenType {
+	case TokenTypePlusPlus, TokenTypeMinusMinus:
+		if expr.Postfix {
+			p.token(expr.Name)
+			p.token(expr.Operator)
+		} else {
+			p.token(expr.Operator)
+			p.token(expr.Name)
+		}
+	default:
+		p.token(expr.Name)
+		p.write(" ")
+		p.token(expr.Operator)
+		p.write(" ")
+		p.expr(expr.Val)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitBinExpr(expr *BinExpr) (interface{}, error) {
+	p.expr(expr.Left)
+	p.write(" ")
+	p.token(expr.Operator)
+	p.write(" ")
+	p.expr(expr.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitCallExpr(expr *CallExpr) (interface{}, error) {
+	p.expr(expr.Callee)
+	p.write("(")
+	p.exprs(expr.Args)
+	p.write(")")
+	return nil, nil
+}
+
+func (p *Printer) VisitConditionalExpr(expr *ConditionalExpr) (interface{}, error) {
+	p.expr(expr.Condition)
+	p.write(" ? ")
+	p.expr(expr.Then)
+	p.write(" : ")
+	p.expr(expr.Else)
+	return nil, nil
+}
+
+func (p *Printer) VisitGroupingExpr(expr *GroupingExpr) (interface{}, error) {
+	p.write("(")
+	p.expr(expr.Expr)
+	p.write(")")
+	return nil, nil
+}
+
+func (p *Printer) VisitIndexGetExpr(expr *IndexGetExpr) (interface{}, error) {
+	p.expr(expr.Object)
+	p.write("[")
+	p.expr(expr.Index)
+	p.write("]")
+	return nil, nil
+}
+
+func (p *Printer) VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, error) {
+	target := func() {
+		p.expr(expr.Object)
+		p.write("[")
+		p.expr(expr.Index)
+		p.write("]")
+	}
+
+	switch expr.Operator.TokenType {
+	case TokenTypePlusPlus, TokenTypeMinusMinus:
+		if expr.Postfix {
+			target()
+			p.token(expr.Operator)
+		} else {
+			p.token(expr.Operator)
+			target()
+		}
+	default:
+		target()
+		p.write(" ")
+		p.token(expr.Operator)
+		p.write(" ")
+		p.expr(expr.Val)
+	}
+	return nil, nil
+}
+
+func (p *Printer) VisitLiteralExpr(expr *LiteralExpr) (interface{}, error) {
+	p.literal(expr.Val)
+	return nil, nil
+}
+
+func (p *Printer) VisitLogicalExpr(expr *LogicalExpr) (interface{}, error) {
+	p.expr(expr.Left)
+	p.write(" ")
+	p.token(expr.Operator)
+	p.write(" ")
+	p.expr(expr.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitMapExpr(expr *MapExpr) (interface{}, error) {
+	p.write("{")
+	for n, key := range expr.Keys {
+		if n > 0 {
+			p.write(", ")
+		}
+		p.token(key)
+		p.write(": ")
+		p.expr(expr.Vals[n])
+	}
+	p.write("}")
+	return nil, nil
+}
+
+func (p *Printer) VisitUnaryExpr(expr *UnaryExpr) (interface{}, error) {
+	p.token(expr.Operator)
+	if expr.Operator.TokenType == TokenTypeMinus && startsWithMinus(expr.Right) {
+		// '- -x' must not become the decrement '--x'.
+		p.write(" ")
+	}
+	p.expr(expr.Right)
+	return nil, nil
+}
+
+func (p *Printer) VisitVarExpr(expr *VarExpr) (interface{}, error) {
+	p.token(expr.Name)
+	return nil, nil
+}
+
+// Signature returns the declaration of a function without its body, such as
+// "fn add(a, b)".
+func Signature(stmt *FuncStmt) string {
+	p := NewPrinter("", nil)
+	p.write("fn ")
+	p.token(stmt.Name)
+	p.write("(")
+	p.patterns(stmt.Params)
+	p.write(")")
+	return p.buf.String()
+}
+
+func literal(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return "\"" + v + "\""
+	}
+	return ""
+}
+
+func startsWithMinus(expr Expr) bool {
+	switch e := expr.(type) {
+	case *UnaryExpr:
+		return e.Operator.TokenType == TokenTypeMinus
+	case *AssignExpr:
+		return !e.Postfix && e.Operator.TokenType == TokenTypeMinusMinus
+	case *IndexSetExpr:
+		return !e.Postfix && e.Operator.TokenType == TokenTypeMinusMinus
+	}
+	return false
+}
+
+// endLine returns the line a token ends on, which differs from Token.Line
+// for comments spanning lines.
+func endLine(comment *Token) int {
+	return comment.Line + strings.Count(comment.Lexeme, "\n")
+}
+
+func tokenLine(token *Token) int {
+	if token == nil {
+		return 0
+	}
+	return token.Line
+}
+
+// StmtLine returns the line a statement starts on, or 0 if it is unknown.
+func StmtLine(stmt Stmt) int {
+	switch s := stmt.(type) {
+	case *BlockStmt:
+		if s.Brace == nil && len(s.Stmts) > 0 {
+			return StmtLine(s.Stmts[len(s.Stmts)-1])
+		}
+		return tokenLine(s.Brace)
+	case *BreakStmt:
+		return tokenLine(s.Keyword)
+	case *ContinueStmt:
+		return tokenLine(s.Keyword)
+	case *DebuggerStmt:
+		return tokenLine(s.Keyword)
+	case *ErrorStmt:
+		if len(s.Tokens) > 0 {
+			return s.Tokens[0].Line
+		}
+	case *ExprStmt:
+		return exprLine(s.Expr)
+	case *ForInStmt:
+		return tokenLine(s.Keyword)
+	case *FuncStmt:
+		return tokenLine(s.Name)
+	case *IfStmt:
+		return tokenLine(s.Keyword)
+	case *MatchStmt:
+		return tokenLine(s.Keyword)
+	case *PrintStmt:
+		return tokenLine(s.Keyword)
+	case *ReturnStmt:
+		return tokenLine(s.Keyword)
+	case *VarStmt:
+		if s.Pattern != nil {
+			return patternLine(s.Pattern)
+		}
+		return tokenLine(s.Name)
+	case *WhileStmt:
+		return tokenLine(s.Keyword)
+	}
+	return 0
+}
+
+// exprLine returns the line of the leftmost token of an expression, or 0 if
+// it has none.
+func exprLine(expr Expr) int {
+	switch e := expr.(type) {
+	case *ArrayExpr:
+		return tokenLine(e.Bracket)
+	case *AssignExpr:
+		return tokenLine(e.Name)
+	case *BinExpr:
+		return exprLine(e.Left)
+	case *CallExpr:
+		return exprLine(e.Callee)
+	case *ConditionalExpr:
+		return exprLine(e.Condition)
+	case *ErrorExpr:
+		if len(e.Tokens) > 0 {
+			return e.Tokens[0].Line
+		}
+	case *GroupingExpr:
+		return exprLine(e.Expr)
+	case *IndexGetExpr:
+		return exprLine(e.Object)
+	case *IndexSetExpr:
+		return exprLine(e.Object)
+	case *LogicalExpr:
+		return exprLine(e.Left)
+	case *MapExpr:
+		return tokenLine(e.Brace)
+	case *UnaryExpr:
+		return tokenLine(e.Operator)
+	case *VarExpr:
+		return tokenLine(e.Name)
+	}
+	return 0
+}
+
+func patternLine(pattern Pattern) int {
+	switch pat := pattern.(type) {
+	case *LiteralPattern:
+		return tokenLine(pat.Token)
+	case *RangePattern:
+		return tokenLine(pat.Token)
+	case *ArrayPattern:
+		return tokenLine(pat.Bracket)
+	case *MapPattern:
+		return tokenLine(pat.Brace)
+	case *BindingPattern:
+		return tokenLine(pat.Name)
+	case *WildcardPattern:
+		return tokenLine(pat.Token)
+	}
+	return 0
+}
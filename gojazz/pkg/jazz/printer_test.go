@@ -0,0 +1,50 @@
+package jazz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFormatGolden formats each testdata/fmt/*.jz and compares the result
+// with the .golden file next to it, which must itself be formatted.
+func TestFormatGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "fmt", "*.jz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden files")
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden, err := os.ReadFile(strings.TrimSuffix(file, ".jz") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Format(string(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(golden) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, golden)
+			}
+
+			again, err := Format(string(golden))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != string(golden) {
+				t.Errorf("golden file is not formatted, formatting it gives:\n%s", again)
+			}
+		})
+	}
+}
@@ -1,7 +1,47 @@
 package jazz
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"strings"
+)
 
-func ReportErr(line int, msg string) {
-	fmt.Printf("[line %d] error: %s\n", line, msg)
+func ReportErr(w io.Writer, line int, msg string) {
+	fmt.Fprintf(w, "[line %d] error: %s\n", line, msg)
+}
+
+// ErrorList collects several errors, such as every syntax or resolution
+// error in a file.
+type ErrorList []error
+
+func (list ErrorList) Error() string {
+	msgs := make([]string, len(list))
+	for n, err := range list {
+		msgs[n] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Err returns the list as an error, or nil if it is empty.
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// ErrorLine returns the source line an error from the scanner, parser or
+// resolver refers to, or 0 if it is unknown.
+func ErrorLine(err error) int {
+	switch e := err.(type) {
+	case *ScannerError:
+		return e.Line
+	case *ParserError:
+		return e.Line
+	case *ResolverError:
+		if e.Token != nil {
+			return e.Token.Line
+		}
+	}
+	return 0
 }
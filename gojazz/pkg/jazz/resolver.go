@@ -22,29 +22,58 @@ func (err *ResolverError) Error() string {
 	return fmt.Sprintf("%s: %s", err.Message, err.Token.Lexeme)
 }
 
+type ResolverOpt func(*ResolverCfg)
+
+type ResolverCfg struct {
+	symbols *Symbols
+}
+
+// WithSymbols makes the resolver record declarations, uses and scopes in
+// symbols.
+func WithSymbols(symbols *Symbols) ResolverOpt {
+	return func(cfg *ResolverCfg) {
+		cfg.symbols = symbols
+	}
+}
+
 type Resolver struct {
+	cfg           *ResolverCfg
 	Interpreter   *Interpreter
 	Scopes        *stack.MapStack
+	Consts        *stack.MapStack
+	slots         []map[string]int // the slot of each name in Scopes
 	CurrFuncType  FuncType
 	CurrLoopDepth int
+	Errors        ErrorList
+	Warnings      []*ResolverError
 }
 
-func NewResolver(interpreter *Interpreter) *Resolver {
+func NewResolver(interpreter *Interpreter, options ...ResolverOpt) *Resolver {
+	cfg := &ResolverCfg{}
+	for _, option := range options {
+		option(cfg)
+	}
+
 	return &Resolver{
+		cfg:         cfg,
 		Interpreter: interpreter,
 		Scopes:      stack.NewMapStack(),
+		Consts:      stack.NewMapStack(),
 	}
 }
 
+// Resolve resolves stmts, recording an error for every statement that fails
+// rather than stopping at the first. The returned error is an ErrorList of
+// everything recorded so far, or nil.
 func (resolver *Resolver) Resolve(stmts []Stmt) error {
+	resolver.resolveStmts(stmts)
+	return resolver.Errors.Err()
+}
+
+func (resolver *Resolver) resolveStmts(stmts []Stmt) {
 	for _, stmt := range stmts {
-		err := resolver.resolveStmt(stmt)
-		if err != nil {
-			return err
-		}
+		resolver.resolveStmt(stmt)
 	}
-
-	return nil
 }
 
 func (resolver *Resolver) resolveExpr(expr Expr) error {
@@ -62,54 +91,104 @@ func (resolver *Resolver) resolveFunc(stmt *FuncStmt, funcType FuncType) error {
 		resolver.CurrLoopDepth = encLoopDepth
 	}()
 
-	resolver.beginScope()
+	resolver.beginScope(stmt.Name, stmt.End)
+	defer resolver.endScope()
 	for _, param := range stmt.Params {
-		err := resolver.declare(param)
+		err := resolver.declarePattern(param)
 		if err != nil {
 			return err
 		}
 
-		err = resolver.define(param)
+		err = resolver.definePattern(param, false)
 		if err != nil {
 			return err
 		}
 	}
-	err := resolver.Resolve(stmt.Body)
-	if err != nil {
-		return err
-	}
-
-	err = resolver.endScope()
-	if err != nil {
-		return err
-	}
+	resolver.resolveStmts(stmt.Body)
 
 	return nil
 }
 
-func (resolver *Resolver) resolveLocal(expr Expr, token *Token) error {
+// resolveBinding finds where the variable named by token lives: in the
+// nearest local scope declaring it, or else in the globals.
+func (resolver *Resolver) resolveBinding(token *Token) *Binding {
+	if resolver.cfg.symbols != nil {
+		resolver.cfg.symbols.use(token)
+	}
+
 	for i := resolver.Scopes.Len() - 1; i >= 0; i-- {
 		m := resolver.Scopes.Get(i)
 		if _, ok := m[token.Lexeme]; ok {
-			return resolver.Interpreter.Resolve(expr, resolver.Scopes.Len()-1-i)
+			return &Binding{Depth: resolver.Scopes.Len() - 1 - i, Slot: resolver.slots[i][token.Lexeme]}
+		}
+	}
+
+	return &Binding{Depth: -1, Slot: resolver.Interpreter.globalEnv.globalSlot(token.Lexeme)}
+}
+
+// checkAssignable reports an error if the nearest declaration of token in the
+// local scopes is a constant. Globals are guarded at runtime instead.
+func (resolver *Resolver) checkAssignable(token *Token) error {
+	for i := resolver.Scopes.Len() - 1; i >= 0; i-- {
+		if _, ok := resolver.Scopes.Get(i)[token.Lexeme]; ok {
+			if resolver.Consts.Get(i)[token.Lexeme] {
+				return &ResolverError{Token: token, Message: "cannot assign to constant"}
+			}
+			return nil
 		}
 	}
 
 	return nil
 }
 
-func (resolver *Resolver) resolveStmt(stmt Stmt) error {
+// resolveStmt resolves stmt and records any error, so that resolution carries
+// on with the next statement.
+func (resolver *Resolver) resolveStmt(stmt Stmt) {
 	_, err := stmt.Accept(resolver)
-	return err
+	if err != nil {
+		resolver.Errors = append(resolver.Errors, err)
+	}
+}
+
+// resolveBody resolves the body of an if or while statement. A function
+// declared as the body may run any number of times, so it gets a scope of its
+// own rather than a slot in the enclosing one.
+func (resolver *Resolver) resolveBody(stmt Stmt) {
+	fn, ok := stmt.(*FuncStmt)
+	if !ok {
+		resolver.resolveStmt(stmt)
+		return
+	}
+
+	resolver.beginScope(fn.Name, fn.End)
+	resolver.resolveStmt(fn)
+	if err := resolver.endScope(); err != nil {
+		resolver.Errors = append(resolver.Errors, err)
+	}
 }
 
-func (resolver *Resolver) beginScope() {
+// beginScope opens a scope. The tokens bounding it are only used for symbols
+// and may be nil.
+func (resolver *Resolver) beginScope(start, end *Token) {
 	m := make(map[string]bool, 0)
 	resolver.Scopes.Push(m)
+	resolver.Consts.Push(make(map[string]bool, 0))
+	resolver.slots = append(resolver.slots, map[string]int{})
+	if resolver.cfg.symbols != nil {
+		resolver.cfg.symbols.beginScope(start, end)
+	}
 }
 
 func (resolver *Resolver) endScope() error {
+	if resolver.cfg.symbols != nil {
+		resolver.cfg.symbols.endScope()
+	}
 	_, err := resolver.Scopes.Pop()
+	if err != nil {
+		return err
+	}
+	resolver.slots = resolver.slots[:len(resolver.slots)-1]
+	_, err = resolver.Consts.Pop()
 	return err
 }
 
@@ -118,10 +197,16 @@ func (resolver *Resolver) declare(token *Token) error {
 		m := resolver.Scopes.Peek()
 		_, ok := m[token.Lexeme]
 		if ok {
-			return fmt.Errorf("variable %s already declared in this scope", token.Lexeme)
+			return &ResolverError{Token: token, Message: "variable already declared in this scope"}
 		}
 
 		m[token.Lexeme] = false
+		slots := resolver.slots[len(resolver.slots)-1]
+		slots[token.Lexeme] = len(slots)
+	}
+
+	if resolver.cfg.symbols != nil {
+		resolver.cfg.symbols.declare(token)
 	}
 
 	return nil
@@ -136,16 +221,53 @@ func (resolver *Resolver) define(token *Token) error {
 	return nil
 }
 
-func (resolver *Resolver) VisitBlockStmt(stmt *BlockStmt) (interface{}, error) {
-	resolver.beginScope()
-	err := resolver.Resolve(stmt.Stmts)
-	if err != nil {
-		return nil, err
+func (resolver *Resolver) defineConst(token *Token) error {
+	if !resolver.Consts.Empty() {
+		m := resolver.Consts.Peek()
+		m[token.Lexeme] = true
+	}
+
+	return resolver.define(token)
+}
+
+func (resolver *Resolver) declarePattern(pattern Pattern) error {
+	for _, name := range patternNames(pattern) {
+		if err := resolver.declare(name); err != nil {
+			return err
+		}
 	}
 
-	err = resolver.endScope()
+	return nil
+}
 
-	return nil, err
+func (resolver *Resolver) definePattern(pattern Pattern, isConst bool) error {
+	for _, name := range patternNames(pattern) {
+		var err error
+		if isConst {
+			err = resolver.defineConst(name)
+		} else {
+			err = resolver.define(name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (resolver *Resolver) VisitBlockStmt(stmt *BlockStmt) (interface{}, error) {
+	start := stmt.Brace
+	if start == nil {
+		// The block around a desugared for-loop starts at its 'for'.
+		if loop, ok := stmt.Stmts[len(stmt.Stmts)-1].(*WhileStmt); ok {
+			start = loop.Keyword
+		}
+	}
+	resolver.beginScope(start, stmtEnd(stmt))
+	resolver.resolveStmts(stmt.Stmts)
+
+	return nil, resolver.endScope()
 }
 
 func (resolver *Resolver) VisitAssignExpr(expr *AssignExpr) (interface{}, error) {
@@ -154,8 +276,13 @@ func (resolver *Resolver) VisitAssignExpr(expr *AssignExpr) (interface{}, error)
 		return nil, err
 	}
 
-	err = resolver.resolveLocal(expr, expr.Name)
-	return nil, err
+	err = resolver.checkAssignable(expr.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	expr.Binding = resolver.resolveBinding(expr.Name)
+	return nil, nil
 }
 
 func (resolver *Resolver) VisitBinExpr(expr *BinExpr) (interface{}, error) {
@@ -184,6 +311,21 @@ func (resolver *Resolver) VisitCallExpr(expr *CallExpr) (interface{}, error) {
 	return nil, nil
 }
 
+func (resolver *Resolver) VisitConditionalExpr(expr *ConditionalExpr) (interface{}, error) {
+	err := resolver.resolveExpr(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	err = resolver.resolveExpr(expr.Then)
+	if err != nil {
+		return nil, err
+	}
+
+	err = resolver.resolveExpr(expr.Else)
+	return nil, err
+}
+
 func (resolver *Resolver) VisitGroupingExpr(expr *GroupingExpr) (interface{}, error) {
 	return nil, resolver.resolveExpr(expr.Expr)
 }
@@ -216,7 +358,16 @@ func (resolver *Resolver) VisitVarExpr(expr *VarExpr) (interface{}, error) {
 		}
 	}
 
-	return nil, resolver.resolveLocal(expr, expr.Name)
+	expr.Binding = resolver.resolveBinding(expr.Name)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitErrorStmt(stmt *ErrorStmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitErrorExpr(expr *ErrorExpr) (interface{}, error) {
+	return nil, nil
 }
 
 func (resolver *Resolver) VisitExprStmt(stmt *ExprStmt) (interface{}, error) {
@@ -246,18 +397,61 @@ func (resolver *Resolver) VisitIfStmt(stmt *IfStmt) (interface{}, error) {
 		return nil, err
 	}
 
-	err = resolver.resolveStmt(stmt.ThenStmt)
+	resolver.resolveBody(stmt.ThenStmt)
+	if stmt.ElseStmt != nil {
+		resolver.resolveBody(stmt.ElseStmt)
+	}
+
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitMatchStmt(stmt *MatchStmt) (interface{}, error) {
+	err := resolver.resolveExpr(stmt.Subject)
 	if err != nil {
 		return nil, err
 	}
 
-	if stmt.ElseStmt != nil {
-		return nil, resolver.resolveStmt(stmt.ElseStmt)
+	hasDefault := false
+	for _, arm := range stmt.Arms {
+		names := []*Token{}
+		for _, pattern := range arm.Patterns {
+			if isIrrefutable(pattern) {
+				hasDefault = true
+			}
+			names = append(names, patternNames(pattern)...)
+		}
+		if len(arm.Patterns) > 1 && len(names) > 0 {
+			return nil, &ResolverError{Token: names[0], Message: "alternative patterns cannot bind names"}
+		}
+
+		if err := resolver.resolveArm(arm); err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasDefault {
+		resolver.Warnings = append(resolver.Warnings, &ResolverError{Token: stmt.Keyword, Message: "match has no default arm"})
 	}
 
 	return nil, nil
 }
 
+func (resolver *Resolver) resolveArm(arm *MatchArm) error {
+	resolver.beginScope(arm.Arrow, stmtEnd(arm.Body))
+	defer resolver.endScope()
+	for _, pattern := range arm.Patterns {
+		if err := resolver.declarePattern(pattern); err != nil {
+			return err
+		}
+		if err := resolver.definePattern(pattern, false); err != nil {
+			return err
+		}
+	}
+	resolver.resolveStmt(arm.Body)
+
+	return nil
+}
+
 func (resolver *Resolver) VisitPrintStmt(stmt *PrintStmt) (interface{}, error) {
 	err := resolver.resolveExpr(stmt.Expr)
 	return nil, err
@@ -277,6 +471,20 @@ func (resolver *Resolver) VisitReturnStmt(stmt *ReturnStmt) (interface{}, error)
 }
 
 func (resolver *Resolver) VisitVarStmt(stmt *VarStmt) (interface{}, error) {
+	if stmt.Pattern != nil {
+		err := resolver.declarePattern(stmt.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		err = resolver.resolveExpr(stmt.Initializer)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, resolver.definePattern(stmt.Pattern, stmt.Const)
+	}
+
 	err := resolver.declare(stmt.Name)
 	if err != nil {
 		return nil, err
@@ -289,7 +497,11 @@ func (resolver *Resolver) VisitVarStmt(stmt *VarStmt) (interface{}, error) {
 		}
 	}
 
-	err = resolver.define(stmt.Name)
+	if stmt.Const {
+		err = resolver.defineConst(stmt.Name)
+	} else {
+		err = resolver.define(stmt.Name)
+	}
 
 	return nil, err
 }
@@ -308,6 +520,10 @@ func (resolver *Resolver) VisitContinueStmt(stmt *ContinueStmt) (interface{}, er
 	return nil, nil
 }
 
+func (resolver *Resolver) VisitDebuggerStmt(_ *DebuggerStmt) (interface{}, error) {
+	return nil, nil
+}
+
 func (resolver *Resolver) VisitWhileStmt(stmt *WhileStmt) (interface{}, error) {
 	resolver.CurrLoopDepth++
 	defer func() { resolver.CurrLoopDepth-- }()
@@ -321,8 +537,33 @@ func (resolver *Resolver) VisitWhileStmt(stmt *WhileStmt) (interface{}, error) {
 			return nil, err
 		}
 	}
-	err = resolver.resolveStmt(stmt.Body)
-	return nil, err
+	resolver.resolveBody(stmt.Body)
+	return nil, nil
+}
+
+func (resolver *Resolver) VisitForInStmt(stmt *ForInStmt) (interface{}, error) {
+	err := resolver.resolveExpr(stmt.Iterable)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver.CurrLoopDepth++
+	defer func() { resolver.CurrLoopDepth-- }()
+
+	resolver.beginScope(stmt.Keyword, stmtEnd(stmt.Body))
+	defer resolver.endScope()
+	err = resolver.declarePattern(stmt.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	err = resolver.definePattern(stmt.Pattern, stmt.Const)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver.resolveStmt(stmt.Body)
+	return nil, nil
 }
 
 func (resolver *Resolver) VisitArrayExpr(expr *ArrayExpr) (interface{}, error) {
@@ -334,6 +575,15 @@ func (resolver *Resolver) VisitArrayExpr(expr *ArrayExpr) (interface{}, error) {
 	return nil, nil
 }
 
+func (resolver *Resolver) VisitMapExpr(expr *MapExpr) (interface{}, error) {
+	for _, val := range expr.Vals {
+		if err := resolver.resolveExpr(val); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 func (resolver *Resolver) VisitIndexGetExpr(expr *IndexGetExpr) (interface{}, error) {
 	if err := resolver.resolveExpr(expr.Object); err != nil {
 		return nil, err
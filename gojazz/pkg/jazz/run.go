@@ -0,0 +1,97 @@
+package jazz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSyntax is returned by Compile when the parser has already reported
+// errors to the interpreter's stderr.
+var ErrSyntax = errors.New("syntax error")
+
+// RuntimeError marks errors raised while interpreting, as opposed to while
+// scanning, parsing or resolving.
+type RuntimeError struct {
+	Err error
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// Compile scans, parses and resolves source for interpreter, reporting syntax
+// errors and resolver warnings to its stderr.
+func Compile(interpreter *Interpreter, source string) ([]Stmt, error) {
+	tokens, err := NewScanner(source).ScanTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	parser := NewParser(tokens, WithErrOutput(interpreter.Stderr()))
+	stmts, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if parser.HasErrors() {
+		return nil, ErrSyntax
+	}
+
+	resolver := NewResolver(interpreter)
+	if err := resolver.Resolve(stmts); err != nil {
+		return nil, err
+	}
+	for _, warning := range resolver.Warnings {
+		fmt.Fprintf(interpreter.Stderr(), "warning: %s\n", warning)
+	}
+
+	return stmts, nil
+}
+
+// Run compiles and interprets source, wrapping errors from interpreting it in
+// a RuntimeError.
+func Run(interpreter *Interpreter, source string) error {
+	stmts, err := Compile(interpreter, source)
+	if err != nil {
+		return err
+	}
+	if err := interpreter.Interpret(stmts); err != nil {
+		return &RuntimeError{Err: err}
+	}
+	return nil
+}
+
+// ExitCode maps an error from Run to a process exit status, following the
+// sysexits convention used by cjazz: 65 for errors in the source, 70 for
+// runtime errors, and the code given to exit().
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	var rerr *RuntimeError
+	if errors.As(err, &rerr) {
+		return 70
+	}
+
+	return 65
+}
+
+// ReportError writes err to stderr, unless it has already been reported or is
+// a call to exit(), and returns the exit status for it. A nil err is 0.
+func ReportError(stderr io.Writer, err error) int {
+	var exitErr *ExitError
+	if err != nil && !errors.As(err, &exitErr) && !errors.Is(err, ErrSyntax) {
+		fmt.Fprintln(stderr, err)
+	}
+	return ExitCode(err)
+}
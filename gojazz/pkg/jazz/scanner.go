@@ -3,6 +3,7 @@ package jazz
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 var ErrTokenNotFound = fmt.Errorf("no token found")
@@ -20,12 +21,28 @@ type ScannerError struct {
 }
 
 func (e *ScannerError) Error() string {
-	return fmt.Sprintf("[line %d] Error%s: %s\n", e.Line, e.Where, e.Message)
+	return fmt.Sprintf("[line %d] Error%s: %s", e.Line, e.Where, e.Message)
+}
+
+type ScannerOpt func(*ScannerCfg)
+
+type ScannerCfg struct {
+	trivia bool
+}
+
+// WithTrivia makes the scanner attach comments to the token that follows
+// them, as Token.Leading.
+func WithTrivia(trivia bool) ScannerOpt {
+	return func(cfg *ScannerCfg) {
+		cfg.trivia = trivia
+	}
 }
 
 type Scanner struct {
+	cfg      *ScannerCfg
 	Source   string
 	Position ScannerPosition
+	pending  []*Token // comments not yet attached to a token
 }
 
 type Configuration struct {
@@ -36,8 +53,13 @@ type Configuration struct {
 	}
 }
 
-func NewScanner(source string) *Scanner {
-	return &Scanner{Source: source, Position: ScannerPosition{
+func NewScanner(source string, options ...ScannerOpt) *Scanner {
+	cfg := &ScannerCfg{}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return &Scanner{cfg: cfg, Source: source, Position: ScannerPosition{
 		Start:   0,
 		Current: 0,
 		Line:    1,
@@ -46,27 +68,45 @@ func NewScanner(source string) *Scanner {
 
 func (scanner *Scanner) ScanTokens() ([]*Token, error) {
 	tokens := make([]*Token, 0)
-	line := 0
 
-	errors := make([]error, 0)
+	scanner.skipShebang()
+
+	errors := ErrorList{}
 	for !scanner.isAtEnd() {
 		scanner.Position.Start = scanner.Position.Current
 		token, err := scanner.findToken()
 		if err != nil && err != ErrTokenNotFound {
 			errors = append(errors, err)
 		} else if token != nil {
-			tokens = append(tokens, token)
+			token.Column = scanner.column(scanner.Position.Start)
+			tokens = append(tokens, scanner.attachTrivia(token))
 		}
 	}
 
-	tokens = append(tokens, NewToken(TokenTypeEOF, "", nil, line))
+	eof := NewToken(TokenTypeEOF, "", nil, scanner.Position.Line)
+	eof.Column = scanner.column(scanner.Position.Current)
+	tokens = append(tokens, scanner.attachTrivia(eof))
+
+	return tokens, ErrorList(errors).Err()
+}
 
-	var err error
-	if len(errors) > 0 {
-		err = fmt.Errorf("%v", errors)
+// skipShebang skips a leading "#!" interpreter line so that scripts can be
+// made executable. The line is kept as a comment and the newline is left for
+// the scanner to count.
+func (scanner *Scanner) skipShebang() {
+	if !strings.HasPrefix(scanner.Source, "#!") {
+		return
 	}
 
-	return tokens, err
+	for !scanner.isAtEnd() && !scanner.peekEq('\n') {
+		scanner.move()
+	}
+	scanner.comment()
+}
+
+// column returns the 1-based column of the byte at offset.
+func (scanner *Scanner) column(offset int) int {
+	return offset - strings.LastIndexByte(scanner.Source[:offset], '\n')
 }
 
 func (scanner *Scanner) isAtEnd() bool {
@@ -134,15 +174,53 @@ func (scanner *Scanner) findToken() (*Token, error) {
 	case ',':
 		return scanner.createToken(TokenTypeComma), nil
 	case '.':
+		if scanner.peekEq('.') {
+			scanner.move()
+			if scanner.peekEq('.') {
+				scanner.move()
+				return scanner.createToken(TokenTypeEllipsis), nil
+			}
+			return scanner.createToken(TokenTypeDotDot), nil
+		}
 		return scanner.createToken(TokenTypeDot), nil
+	case ':':
+		return scanner.createToken(TokenTypeColon), nil
+	case '?':
+		return scanner.createToken(TokenTypeQuestion), nil
 	case '-':
+		if scanner.peekEq('-') {
+			scanner.move()
+			return scanner.createToken(TokenTypeMinusMinus), nil
+		}
+		if scanner.peekEq('=') {
+			scanner.move()
+			return scanner.createToken(TokenTypeMinusEq), nil
+		}
 		return scanner.createToken(TokenTypeMinus), nil
 	case '+':
+		if scanner.peekEq('+') {
+			scanner.move()
+			return scanner.createToken(TokenTypePlusPlus), nil
+		}
+		if scanner.peekEq('=') {
+			scanner.move()
+			return scanner.createToken(TokenTypePlusEq), nil
+		}
 		return scanner.createToken(TokenTypePlus), nil
 	case ';':
 		return scanner.createToken(TokenTypeSemicolon), nil
 	case '*':
+		if scanner.peekEq('=') {
+			scanner.move()
+			return scanner.createToken(TokenTypeStarEq), nil
+		}
 		return scanner.createToken(TokenTypeStar), nil
+	case '%':
+		if scanner.peekEq('=') {
+			scanner.move()
+			return scanner.createToken(TokenTypePercentEq), nil
+		}
+		return scanner.createToken(TokenTypePercent), nil
 	case '!':
 		if scanner.peekEq('=') {
 			scanner.move()
@@ -154,6 +232,10 @@ func (scanner *Scanner) findToken() (*Token, error) {
 			scanner.move()
 			return scanner.createToken(TokenTypeEqEq), nil
 		}
+		if scanner.peekEq('>') {
+			scanner.move()
+			return scanner.createToken(TokenTypeArrow), nil
+		}
 		return scanner.createToken(TokenTypeEq), nil
 	case '<':
 		if scanner.peekEq('=') {
@@ -169,20 +251,18 @@ func (scanner *Scanner) findToken() (*Token, error) {
 		return scanner.createToken(TokenTypeGreater), nil
 	case '/':
 		if scanner.peekEq('*') {
-			for {
-				if (scanner.peekEq('*') && scanner.peekNextEq('/')) || scanner.isAtEnd() {
-					return nil, ErrTokenNotFound
-				}
-				scanner.move()
-			}
+			return nil, scanner.blockComment()
 		}
 		if scanner.peekEq('/') {
-			for {
-				if scanner.peekEq('\n') || scanner.isAtEnd() {
-					return nil, ErrTokenNotFound
-				}
+			for !scanner.peekEq('\n') && !scanner.isAtEnd() {
 				scanner.move()
 			}
+			scanner.comment()
+			return nil, ErrTokenNotFound
+		}
+		if scanner.peekEq('=') {
+			scanner.move()
+			return scanner.createToken(TokenTypeSlashEq), nil
 		}
 		return scanner.createToken(TokenTypeSlash), nil
 	case ' ':
@@ -212,6 +292,51 @@ func (scanner *Scanner) findToken() (*Token, error) {
 	}
 }
 
+// blockComment scans a '/* */' comment, which may nest and span lines.
+func (scanner *Scanner) blockComment() error {
+	line := scanner.Position.Line
+	scanner.move()
+	depth := 1
+	for depth > 0 {
+		if scanner.isAtEnd() {
+			return &ScannerError{Line: line, Message: "unterminated block comment"}
+		}
+		switch {
+		case scanner.peekEq('/') && scanner.peekNextEq('*'):
+			scanner.move()
+			depth++
+		case scanner.peekEq('*') && scanner.peekNextEq('/'):
+			scanner.move()
+			depth--
+		case scanner.peekEq('\n'):
+			scanner.moveLine()
+		}
+		scanner.move()
+	}
+
+	scanner.comment().Line = line
+	return ErrTokenNotFound
+}
+
+// comment records the comment just scanned.
+func (scanner *Scanner) comment() *Token {
+	token := scanner.createToken(TokenTypeComment)
+	token.Column = scanner.column(scanner.Position.Start)
+	token.Lexeme = strings.TrimRight(token.Lexeme, " \t\r")
+	if scanner.cfg.trivia {
+		scanner.pending = append(scanner.pending, token)
+	}
+	return token
+}
+
+func (scanner *Scanner) attachTrivia(token *Token) *Token {
+	if len(scanner.pending) > 0 {
+		token.Leading = scanner.pending
+		scanner.pending = nil
+	}
+	return token
+}
+
 func (scanner *Scanner) parseString() (*Token, error) {
 	for !scanner.peekEq('"') && !scanner.isAtEnd() {
 		if scanner.peekEq('\n') {
@@ -221,7 +346,7 @@ func (scanner *Scanner) parseString() (*Token, error) {
 	}
 
 	if scanner.isAtEnd() {
-		return nil, fmt.Errorf("unterminated string")
+		return nil, &ScannerError{Line: scanner.Position.Line, Message: "unterminated string"}
 	}
 
 	scanner.move()
@@ -4,9 +4,13 @@ type StmtVisitor interface {
 	VisitBlockStmt(stmt *BlockStmt) (interface{}, error)
 	VisitBreakStmt(stmt *BreakStmt) (interface{}, error)
 	VisitContinueStmt(stmt *ContinueStmt) (interface{}, error)
+	VisitDebuggerStmt(stmt *DebuggerStmt) (interface{}, error)
+	VisitErrorStmt(stmt *ErrorStmt) (interface{}, error)
 	VisitExprStmt(stmt *ExprStmt) (interface{}, error)
+	VisitForInStmt(stmt *ForInStmt) (interface{}, error)
 	VisitFuncStmt(stmt *FuncStmt) (interface{}, error)
 	VisitIfStmt(stmt *IfStmt) (interface{}, error)
+	VisitMatchStmt(stmt *MatchStmt) (interface{}, error)
 	VisitPrintStmt(stmt *PrintStmt) (interface{}, error)
 	VisitReturnStmt(stmt *ReturnStmt) (interface{}, error)
 	VisitVarStmt(stmt *VarStmt) (interface{}, error)
@@ -18,38 +22,74 @@ type Stmt interface {
 }
 
 type BlockStmt struct {
+	Brace *Token // nil for the block wrapping a desugared for-loop
 	Stmts []Stmt
+	End   *Token
 	Env   *Env
 }
 
+// ErrorStmt stands in for a statement that failed to parse in recovery mode.
+// Tokens are the tokens skipped over.
+type ErrorStmt struct {
+	Tokens []*Token
+}
+
 type ExprStmt struct {
 	Expr Expr
 }
 
+type ForInStmt struct {
+	Keyword  *Token
+	Pattern  Pattern
+	Iterable Expr
+	Body     Stmt
+	Const    bool
+}
+
 type FuncStmt struct {
+	Doc    string // '///' comment before the declaration, if scanned WithTrivia
 	Name   *Token
-	Params []*Token
+	Params []Pattern
 	Body   []Stmt
+	End    *Token
 }
 
 type IfStmt struct {
+	Keyword   *Token
 	Condition Expr
 	ThenStmt  Stmt
 	ElseStmt  Stmt
 }
 
+type MatchStmt struct {
+	Keyword *Token
+	Subject Expr
+	Arms    []*MatchArm
+	End     *Token
+}
+
+type MatchArm struct {
+	Patterns []Pattern
+	Arrow    *Token
+	Body     Stmt
+}
+
 type ReturnStmt struct {
 	Keyword *Token
 	Val     Expr
 }
 
 type PrintStmt struct {
-	Expr Expr
+	Keyword *Token
+	Expr    Expr
 }
 
 type VarStmt struct {
+	Doc         string // '///' comment before the declaration, if scanned WithTrivia
 	Name        *Token
+	Pattern     Pattern // set instead of Name for destructuring declarations
 	Initializer Expr
+	Const       bool // declared with 'const', cannot be reassigned
 }
 
 type BreakStmt struct {
@@ -60,7 +100,14 @@ type ContinueStmt struct {
 	Keyword *Token
 }
 
+// DebuggerStmt pauses the program when it runs under a debugger and does
+// nothing otherwise.
+type DebuggerStmt struct {
+	Keyword *Token
+}
+
 type WhileStmt struct {
+	Keyword   *Token // 'while', or 'for' for desugared for-loops
 	Condition Expr
 	Body      Stmt
 	Increment Expr // non-nil for desugared for-loops
@@ -78,10 +125,22 @@ func (stmt *ContinueStmt) Accept(v StmtVisitor) (interface{}, error) {
 	return v.VisitContinueStmt(stmt)
 }
 
+func (stmt *DebuggerStmt) Accept(v StmtVisitor) (interface{}, error) {
+	return v.VisitDebuggerStmt(stmt)
+}
+
+func (stmt *ErrorStmt) Accept(v StmtVisitor) (interface{}, error) {
+	return v.VisitErrorStmt(stmt)
+}
+
 func (stmt *ExprStmt) Accept(v StmtVisitor) (interface{}, error) {
 	return v.VisitExprStmt(stmt)
 }
 
+func (stmt *ForInStmt) Accept(v StmtVisitor) (interface{}, error) {
+	return v.VisitForInStmt(stmt)
+}
+
 func (stmt *FuncStmt) Accept(v StmtVisitor) (interface{}, error) {
 	return v.VisitFuncStmt(stmt)
 }
@@ -90,6 +149,10 @@ func (stmt *IfStmt) Accept(v StmtVisitor) (interface{}, error) {
 	return v.VisitIfStmt(stmt)
 }
 
+func (stmt *MatchStmt) Accept(v StmtVisitor) (interface{}, error) {
+	return v.VisitMatchStmt(stmt)
+}
+
 func (stmt *PrintStmt) Accept(v StmtVisitor) (interface{}, error) {
 	return v.VisitPrintStmt(stmt)
 }
@@ -0,0 +1,106 @@
+package jazz
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var stringNatives = map[string]Callable{
+	"str":      &Native{Name: "str", Params: 1, Fn: str},
+	"num":      &Native{Name: "num", Params: 1, Fn: num},
+	"upper":    &Native{Name: "upper", Params: 1, Fn: upper},
+	"lower":    &Native{Name: "lower", Params: 1, Fn: lower},
+	"trim":     &Native{Name: "trim", Params: 1, Fn: trim},
+	"contains": &Native{Name: "contains", Params: 2, Fn: contains},
+	"replace":  &Native{Name: "replace", Params: 3, Fn: replace},
+	"split":    &Native{Name: "split", Params: 2, Fn: split},
+	"join":     &Native{Name: "join", Params: 2, Fn: join},
+	"substr":   &Native{Name: "substr", Params: 3, Fn: substr},
+}
+
+// newString charges a string built by a native to the allocation limit.
+func newString(i *Interpreter, s string) interface{} {
+	result, err := i.allocString(s)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func str(i *Interpreter, args []interface{}) interface{} {
+	return newString(i, stringify(args[0]))
+}
+
+// num parses a string as a number, returning nil if it is not one.
+func num(_ *Interpreter, args []interface{}) interface{} {
+	if f, ok := args[0].(float64); ok {
+		return f
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(stringArg("num", args, 0)), 64)
+	if err != nil {
+		return nil
+	}
+	return f
+}
+
+func upper(i *Interpreter, args []interface{}) interface{} {
+	return newString(i, strings.ToUpper(stringArg("upper", args, 0)))
+}
+
+func lower(i *Interpreter, args []interface{}) interface{} {
+	return newString(i, strings.ToLower(stringArg("lower", args, 0)))
+}
+
+func trim(i *Interpreter, args []interface{}) interface{} {
+	return newString(i, strings.TrimSpace(stringArg("trim", args, 0)))
+}
+
+func contains(_ *Interpreter, args []interface{}) interface{} {
+	return strings.Contains(stringArg("contains", args, 0), stringArg("contains", args, 1))
+}
+
+func replace(i *Interpreter, args []interface{}) interface{} {
+	return newString(i, strings.ReplaceAll(stringArg("replace", args, 0), stringArg("replace", args, 1), stringArg("replace", args, 2)))
+}
+
+func split(i *Interpreter, args []interface{}) interface{} {
+	s := stringArg("split", args, 0)
+	parts := strings.Split(s, stringArg("split", args, 1))
+	if err := i.allocate(len(parts)*valueSize + len(s)); err != nil {
+		panic(err)
+	}
+	elements := make([]interface{}, len(parts))
+	for n, part := range parts {
+		elements[n] = part
+	}
+	return NewJazzArray(elements)
+}
+
+func join(i *Interpreter, args []interface{}) interface{} {
+	arr := arrayArg("join", args, 0)
+	parts := make([]string, len(arr.Elements))
+	for n, el := range arr.Elements {
+		parts[n] = stringify(el)
+	}
+	return newString(i, strings.Join(parts, stringArg("join", args, 1)))
+}
+
+// substr returns the characters of a string from start up to, but not
+// including, end. Indexes are clamped to the string.
+func substr(i *Interpreter, args []interface{}) interface{} {
+	s := stringArg("substr", args, 0)
+	start := int(numberArg("substr", args, 1))
+	end := int(numberArg("substr", args, 2))
+	length := utf8.RuneCountInString(s)
+	if start < 0 {
+		start = 0
+	}
+	if end > length {
+		end = length
+	}
+	if start >= end {
+		return ""
+	}
+	return newString(i, string([]rune(s)[start:end]))
+}
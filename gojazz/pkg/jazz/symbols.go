@@ -0,0 +1,201 @@
+package jazz
+
+import (
+	"math"
+	"sort"
+)
+
+// Position is a 1-based line and column in a source file.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func tokenStart(token *Token) Position {
+	return Position{Line: token.Line, Column: token.Column}
+}
+
+func tokenEnd(token *Token) Position {
+	return Position{Line: token.Line, Column: token.Column + len(token.Lexeme)}
+}
+
+func (pos Position) before(other Position) bool {
+	return pos.Line < other.Line || (pos.Line == other.Line && pos.Column < other.Column)
+}
+
+// Scope is a lexical scope seen by the resolver. Names maps every name
+// declared directly in the scope to its declaration.
+type Scope struct {
+	Parent *Scope
+	Start  Position
+	End    Position
+	Names  map[string]*Token
+}
+
+func (scope *Scope) contains(pos Position) bool {
+	return !pos.before(scope.Start) && pos.before(scope.End)
+}
+
+// Symbols records where names are declared and used, for editor tooling. It is
+// filled in by a resolver created WithSymbols.
+type Symbols struct {
+	Globals *Scope
+	Decls   []*Token // every declaration, in source order
+	Uses    []*Token // every variable read or assignment, in the order resolved
+
+	declared map[*Token]bool
+	refs     map[*Token]*Token // use to local declaration, nil for globals
+	scopes   []*Scope
+	open     []*Scope
+}
+
+func NewSymbols() *Symbols {
+	globals := &Scope{
+		Start: Position{Line: 1, Column: 1},
+		End:   Position{Line: math.MaxInt, Column: math.MaxInt},
+		Names: map[string]*Token{},
+	}
+
+	return &Symbols{
+		Globals:  globals,
+		declared: map[*Token]bool{},
+		refs:     map[*Token]*Token{},
+		open:     []*Scope{globals},
+	}
+}
+
+func (symbols *Symbols) current() *Scope {
+	return symbols.open[len(symbols.open)-1]
+}
+
+// beginScope opens a scope running from start to the end of end. Without an
+// end token the scope is taken to end with the line it starts on.
+func (symbols *Symbols) beginScope(start, end *Token) {
+	scope := &Scope{Parent: symbols.current(), Names: map[string]*Token{}}
+	if start != nil {
+		scope.Start = tokenStart(start)
+		scope.End = Position{Line: start.Line, Column: math.MaxInt}
+	}
+	if end != nil {
+		scope.End = tokenEnd(end)
+	}
+
+	symbols.scopes = append(symbols.scopes, scope)
+	symbols.open = append(symbols.open, scope)
+}
+
+func (symbols *Symbols) endScope() {
+	symbols.open = symbols.open[:len(symbols.open)-1]
+}
+
+func (symbols *Symbols) declare(token *Token) {
+	symbols.current().Names[token.Lexeme] = token
+	symbols.Decls = append(symbols.Decls, token)
+	symbols.declared[token] = true
+}
+
+func (symbols *Symbols) use(token *Token) {
+	symbols.Uses = append(symbols.Uses, token)
+	for n := len(symbols.open) - 1; n > 0; n-- {
+		if decl, ok := symbols.open[n].Names[token.Lexeme]; ok {
+			symbols.refs[token] = decl
+			return
+		}
+	}
+	symbols.refs[token] = nil
+}
+
+// Definition returns the declaration a name token refers to: itself for a
+// declaration, the binding in scope for a use, or nil for natives and
+// undefined names. Uses of globals resolve to the last global declaration of
+// that name.
+func (symbols *Symbols) Definition(token *Token) *Token {
+	if symbols.declared[token] {
+		return token
+	}
+
+	decl, ok := symbols.refs[token]
+	if !ok {
+		return nil
+	}
+	if decl == nil {
+		decl = symbols.Globals.Names[token.Lexeme]
+	}
+	return decl
+}
+
+// References returns every use of decl, in source order.
+func (symbols *Symbols) References(decl *Token) []*Token {
+	refs := []*Token{}
+	for _, use := range symbols.Uses {
+		if symbols.Definition(use) == decl {
+			refs = append(refs, use)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return tokenStart(refs[i]).before(tokenStart(refs[j]))
+	})
+	return refs
+}
+
+// ScopeAt returns the innermost scope containing pos.
+func (symbols *Symbols) ScopeAt(pos Position) *Scope {
+	scope := symbols.Globals
+	for _, s := range symbols.scopes {
+		if s.contains(pos) && !s.Start.before(scope.Start) {
+			scope = s
+		}
+	}
+	return scope
+}
+
+// NamesAt returns the declarations visible at pos, innermost first. Locals
+// are only visible after their declaration; globals anywhere.
+func (symbols *Symbols) NamesAt(pos Position) []*Token {
+	names := []*Token{}
+	seen := map[string]bool{}
+	for scope := symbols.ScopeAt(pos); scope != nil; scope = scope.Parent {
+		decls := make([]*Token, 0, len(scope.Names))
+		for _, decl := range scope.Names {
+			decls = append(decls, decl)
+		}
+		sort.Slice(decls, func(i, j int) bool {
+			return tokenStart(decls[i]).before(tokenStart(decls[j]))
+		})
+
+		for _, decl := range decls {
+			if seen[decl.Lexeme] || (scope != symbols.Globals && !tokenStart(decl).before(pos)) {
+				continue
+			}
+			seen[decl.Lexeme] = true
+			names = append(names, decl)
+		}
+	}
+	return names
+}
+
+// stmtEnd returns the last token of stmt that the AST keeps, or nil if it
+// keeps none.
+func stmtEnd(stmt Stmt) *Token {
+	switch s := stmt.(type) {
+	case *BlockStmt:
+		if s.Brace == nil && len(s.Stmts) > 0 {
+			return stmtEnd(s.Stmts[len(s.Stmts)-1])
+		}
+		return s.End
+	case *ForInStmt:
+		return stmtEnd(s.Body)
+	case *FuncStmt:
+		return s.End
+	case *IfStmt:
+		if s.ElseStmt != nil {
+			return stmtEnd(s.ElseStmt)
+		}
+		return stmtEnd(s.ThenStmt)
+	case *MatchStmt:
+		return s.End
+	case *WhileStmt:
+		return stmtEnd(s.Body)
+	}
+	return nil
+}
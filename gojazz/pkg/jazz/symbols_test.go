@@ -0,0 +1,120 @@
+package jazz
+
+import (
+	"io"
+	"testing"
+)
+
+// resolveSymbols resolves source, recording its symbols.
+func resolveSymbols(t *testing.T, source string) *Symbols {
+	t.Helper()
+	tokens, err := NewScanner(source).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts, err := NewParser(tokens, WithErrOutput(io.Discard)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := NewSymbols()
+	if err := NewResolver(NewInterpreter(), WithSymbols(symbols)).Resolve(stmts); err != nil {
+		t.Fatal(err)
+	}
+	return symbols
+}
+
+// uses returns the uses of name, in the order they were resolved.
+func uses(symbols *Symbols, name string) []*Token {
+	found := []*Token{}
+	for _, use := range symbols.Uses {
+		if use.Lexeme == name {
+			found = append(found, use)
+		}
+	}
+	return found
+}
+
+func decl(t *testing.T, symbols *Symbols, name string, line int) *Token {
+	t.Helper()
+	for _, decl := range symbols.Decls {
+		if decl.Lexeme == name && decl.Line == line {
+			return decl
+		}
+	}
+	t.Fatalf("no declaration of %s on line %d", name, line)
+	return nil
+}
+
+const symbolsSource = `let x = 1;
+fn f(a) {
+    let x = a;
+    print x;
+    return a + 1;
+}
+print f(x);
+x = 2;
+x = x + 1;
+print len("x");
+print y;
+`
+
+func TestDefinition(t *testing.T) {
+	symbols := resolveSymbols(t, symbolsSource)
+	global, local, param := decl(t, symbols, "x", 1), decl(t, symbols, "x", 3), decl(t, symbols, "a", 2)
+
+	xs := uses(symbols, "x")
+	tests := []struct {
+		name  string
+		token *Token
+		want  *Token
+	}{
+		{"a declaration is its own definition", global, global},
+		{"a local shadows the global", xs[0], local},
+		{"a parameter", uses(symbols, "a")[0], param},
+		{"a global read", xs[1], global},
+		{"a global assignment", xs[2], global},
+		{"a global function", uses(symbols, "f")[0], decl(t, symbols, "f", 2)},
+		{"a native", uses(symbols, "len")[0], nil},
+		{"an undefined name", uses(symbols, "y")[0], nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			if got := symbols.Definition(test.token); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	symbols := resolveSymbols(t, symbolsSource)
+	xs := uses(symbols, "x")
+
+	tests := []struct {
+		name string
+		decl *Token
+		want []*Token
+	}{
+		{"a global, in source order", decl(t, symbols, "x", 1), []*Token{xs[1], xs[2], xs[4], xs[3]}},
+		{"a local", decl(t, symbols, "x", 3), []*Token{xs[0]}},
+		{"a parameter", decl(t, symbols, "a", 2), uses(symbols, "a")},
+		{"a function", decl(t, symbols, "f", 2), uses(symbols, "f")},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got := symbols.References(test.decl)
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d references, want %d", len(got), len(test.want))
+			}
+			for n := range got {
+				if got[n] != test.want[n] {
+					t.Errorf("reference %d is on line %d, want line %d", n, got[n].Line, test.want[n].Line)
+				}
+			}
+		})
+	}
+}
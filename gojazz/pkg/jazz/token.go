@@ -1,6 +1,9 @@
 package jazz
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type TokenType int
 
@@ -12,23 +15,36 @@ const (
 	TokenTypeRightBrace
 	TokenTypeLeftBracket
 	TokenTypeRightBracket
+	TokenTypeColon
 	TokenTypeComma
 	TokenTypeDot
 	TokenTypeMinus
 	TokenTypePlus
 	TokenTypeSemicolon
+	TokenTypePercent
+	TokenTypeQuestion
 	TokenTypeSlash
 	TokenTypeStar
 
 	// One or two character tokens.
+	TokenTypeArrow
 	TokenTypeBang
 	TokenTypeBangEq
+	TokenTypeDotDot
+	TokenTypeEllipsis
 	TokenTypeEq
 	TokenTypeEqEq
 	TokenTypeGreater
 	TokenTypeGreaterEq
 	TokenTypeLess
 	TokenTypeLessEq
+	TokenTypeMinusEq
+	TokenTypeMinusMinus
+	TokenTypePercentEq
+	TokenTypePlusEq
+	TokenTypePlusPlus
+	TokenTypeSlashEq
+	TokenTypeStarEq
 
 	// Literals.
 	TokenTypeIdentifier
@@ -38,12 +54,16 @@ const (
 	//Keywords
 	TokenTypeAnd
 	TokenTypeBreak
+	TokenTypeConst
 	TokenTypeContinue
+	TokenTypeDebugger
 	TokenTypeElse
 	TokenTypeFalse
 	TokenTypeFunc
 	TokenTypeFor
 	TokenTypeIf
+	TokenTypeIn
+	TokenTypeMatch
 	TokenTypeNil
 	TokenTypeOr
 	TokenTypePrint
@@ -52,25 +72,40 @@ const (
 	TokenTypeVar
 	TokenTypeWhile
 
+	TokenTypeComment
 	TokenTypeEOF
 )
 
 var keywords = map[string]TokenType{
 	"and":      TokenTypeAnd,
 	"break":    TokenTypeBreak,
+	"const":    TokenTypeConst,
 	"continue": TokenTypeContinue,
+	"debugger": TokenTypeDebugger,
 	"else":     TokenTypeElse,
-	"false":  TokenTypeFalse,
-	"for":    TokenTypeFor,
-	"fn":     TokenTypeFunc,
-	"if":     TokenTypeIf,
-	"nil":    TokenTypeNil,
-	"or":     TokenTypeOr,
-	"print":  TokenTypePrint,
-	"return": TokenTypeReturn,
-	"true":   TokenTypeTrue,
-	"let":    TokenTypeVar,
-	"while":  TokenTypeWhile,
+	"false":    TokenTypeFalse,
+	"for":      TokenTypeFor,
+	"fn":       TokenTypeFunc,
+	"if":       TokenTypeIf,
+	"in":       TokenTypeIn,
+	"match":    TokenTypeMatch,
+	"nil":      TokenTypeNil,
+	"or":       TokenTypeOr,
+	"print":    TokenTypePrint,
+	"return":   TokenTypeReturn,
+	"true":     TokenTypeTrue,
+	"let":      TokenTypeVar,
+	"while":    TokenTypeWhile,
+}
+
+// Keywords returns the reserved words of the language, sorted.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 type Token struct {
@@ -78,6 +113,8 @@ type Token struct {
 	Lexeme    string
 	Literal   interface{}
 	Line      int
+	Column    int      // 1-based byte offset of the token in its line
+	Leading   []*Token // comments before the token, when scanned WithTrivia
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int) *Token {
@@ -11,5 +11,5 @@ func isDigit(r rune) bool {
 }
 
 func isAlpha(r rune) bool {
-	return unicode.IsLetter(r)
+	return unicode.IsLetter(r) || r == '_'
 }
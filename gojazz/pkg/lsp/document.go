@@ -0,0 +1,208 @@
+package lsp
+
+import (
+	"io"
+	"strings"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+// document is an open text document and everything learnt by scanning,
+// parsing and resolving it.
+type document struct {
+	uri         string
+	lines       []string
+	tokens      []*jazz.Token
+	stmts       []jazz.Stmt
+	symbols     *jazz.Symbols
+	diagnostics []Diagnostic
+	funcs       map[*jazz.Token]*jazz.FuncStmt // by name
+	vars        map[*jazz.Token]*jazz.VarStmt  // by name
+}
+
+func analyze(uri, text string, capabilities []string) *document {
+	doc := &document{
+		uri:         uri,
+		lines:       strings.Split(text, "\n"),
+		symbols:     jazz.NewSymbols(),
+		diagnostics: []Diagnostic{},
+		funcs:       map[*jazz.Token]*jazz.FuncStmt{},
+		vars:        map[*jazz.Token]*jazz.VarStmt{},
+	}
+
+	tokens, err := jazz.NewScanner(text, jazz.WithTrivia(true)).ScanTokens()
+	doc.tokens = tokens
+	doc.addErrors(SeverityError, err)
+
+	parser := jazz.NewParser(tokens, jazz.WithErrOutput(io.Discard), jazz.WithRecovery(true))
+	doc.stmts, err = parser.Parse()
+	doc.addErrors(SeverityError, err)
+	doc.index(doc.stmts)
+
+	// Resolve even a partial tree for its symbols, but only report resolver
+	// errors once the syntax is right, since they may be follow-on errors.
+	interpreter := jazz.NewInterpreter(jazz.WithCapabilities(capabilities...), jazz.WithStdout(io.Discard), jazz.WithStderr(io.Discard))
+	resolver := jazz.NewResolver(interpreter, jazz.WithSymbols(doc.symbols))
+	err = resolver.Resolve(doc.stmts)
+	if len(doc.diagnostics) == 0 {
+		doc.addErrors(SeverityError, err)
+		for _, warning := range resolver.Warnings {
+			doc.addErrors(SeverityWarning, warning)
+		}
+	}
+
+	return doc
+}
+
+func (doc *document) addErrors(severity int, err error) {
+	if err == nil {
+		return
+	}
+	if list, ok := err.(jazz.ErrorList); ok {
+		for _, err := range list {
+			doc.addErrors(severity, err)
+		}
+		return
+	}
+
+	d := Diagnostic{Severity: severity, Source: "jazz", Message: err.Error()}
+	switch e := err.(type) {
+	case *jazz.ScannerError:
+		d.Range = doc.lineRange(e.Line)
+		d.Message = e.Message
+	case *jazz.ParserError:
+		d.Range = doc.lineRange(e.Line)
+		if token := doc.tokenStartingAt(e.Line, e.Column); token != nil {
+			d.Range = doc.tokenRange(token)
+		}
+		d.Message = e.Message
+	case *jazz.ResolverError:
+		d.Range = doc.tokenRange(e.Token)
+		d.Message = e.Message
+	}
+	doc.diagnostics = append(doc.diagnostics, d)
+}
+
+// index records the functions and variables declared anywhere in stmts.
+func (doc *document) index(stmts []jazz.Stmt) {
+	for _, stmt := range stmts {
+		doc.indexStmt(stmt)
+	}
+}
+
+func (doc *document) indexStmt(stmt jazz.Stmt) {
+	switch s := stmt.(type) {
+	case *jazz.BlockStmt:
+		doc.index(s.Stmts)
+	case *jazz.ForInStmt:
+		doc.indexStmt(s.Body)
+	case *jazz.FuncStmt:
+		doc.funcs[s.Name] = s
+		doc.index(s.Body)
+	case *jazz.IfStmt:
+		doc.indexStmt(s.ThenStmt)
+		if s.ElseStmt != nil {
+			doc.indexStmt(s.ElseStmt)
+		}
+	case *jazz.MatchStmt:
+		for _, arm := range s.Arms {
+			doc.indexStmt(arm.Body)
+		}
+	case *jazz.VarStmt:
+		if s.Name != nil {
+			doc.vars[s.Name] = s
+		}
+	case *jazz.WhileStmt:
+		doc.indexStmt(s.Body)
+	}
+}
+
+// identifierAt returns the identifier under pos, if any.
+func (doc *document) identifierAt(pos Position) *jazz.Token {
+	at := doc.position(pos)
+	for _, token := range doc.tokens {
+		if token.TokenType != jazz.TokenTypeIdentifier || token.Line != at.Line {
+			continue
+		}
+		if at.Column >= token.Column && at.Column <= token.Column+len(token.Lexeme) {
+			return token
+		}
+	}
+	return nil
+}
+
+func (doc *document) tokenStartingAt(line, column int) *jazz.Token {
+	for _, token := range doc.tokens {
+		if token.Line == line && token.Column == column && token.TokenType != jazz.TokenTypeEOF {
+			return token
+		}
+	}
+	return nil
+}
+
+func (doc *document) lineRange(line int) Range {
+	return Range{
+		Start: Position{Line: line - 1},
+		End:   Position{Line: line - 1, Character: utf16Len(doc.line(line))},
+	}
+}
+
+func (doc *document) location(token *jazz.Token) Location {
+	return Location{URI: doc.uri, Range: doc.tokenRange(token)}
+}
+
+func (doc *document) tokenRange(token *jazz.Token) Range {
+	return Range{
+		Start: Position{Line: token.Line - 1, Character: doc.character(token.Line, token.Column)},
+		End:   Position{Line: token.Line - 1, Character: doc.character(token.Line, token.Column+len(token.Lexeme))},
+	}
+}
+
+// line returns the text of a 1-based line, without its line ending.
+func (doc *document) line(line int) string {
+	if line < 1 || line > len(doc.lines) {
+		return ""
+	}
+	return strings.TrimRight(doc.lines[line-1], "\r")
+}
+
+// character converts a 1-based byte column of the scanner to the 0-based
+// UTF-16 offset of LSP positions.
+func (doc *document) character(line, column int) int {
+	text := doc.line(line)
+	n := column - 1
+	if n > len(text) {
+		n = len(text)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return utf16Len(text[:n])
+}
+
+// position converts an LSP position to the 1-based line and byte column of
+// the scanner.
+func (doc *document) position(pos Position) jazz.Position {
+	text := doc.line(pos.Line + 1)
+	units := 0
+	for n, r := range text {
+		if units >= pos.Character {
+			return jazz.Position{Line: pos.Line + 1, Column: n + 1}
+		}
+		units += utf16Len(string(r))
+	}
+	return jazz.Position{Line: pos.Line + 1, Column: len(text) + 1}
+}
+
+// utf16Len returns the number of UTF-16 code units encoding s.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,402 @@
+// Package lsp implements a Language Server Protocol server for Jazz over a
+// pair of streams, usually stdin and stdout.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/internal/wire"
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+// ErrNoShutdown is returned by Run when the client exits without asking the
+// server to shut down first.
+var ErrNoShutdown = errors.New("lsp: exit without shutdown")
+
+type Server struct {
+	in           *bufio.Reader
+	out          io.Writer
+	capabilities []string
+	docs         map[string]*document
+	shutdown     bool
+}
+
+// NewServer returns a server reading requests from in and writing responses to
+// out. Documents are analysed as if granted capabilities.
+func NewServer(in io.Reader, out io.Writer, capabilities []string) *Server {
+	return &Server{
+		in:           bufio.NewReader(in),
+		out:          out,
+		capabilities: capabilities,
+		docs:         map[string]*document{},
+	}
+}
+
+// Run serves requests until the client sends exit or closes the input.
+func (s *Server) Run() error {
+	for {
+		body, err := wire.Read(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(&req)
+		if req.ID == nil {
+			continue
+		}
+		if err := s.reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+// read reads the body of the next message.
+func (s *Server) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return wire.Write(s.out, body)
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) error {
+	if rerr != nil {
+		return s.write(&errorResponse{JSONRPC: "2.0", ID: id, Error: rerr})
+	}
+	return s.write(&response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(&notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req *request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.publish(params.TextDocument.URI, []Diagnostic{})
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params)
+	case "textDocument/references":
+		var params referenceParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.references(params)
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params)
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbols(params)
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params)
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+}
+
+func unmarshal(params json.RawMessage, v interface{}) *responseError {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize() interface{} {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":       1, // full
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"documentSymbolProvider": true,
+			"completionProvider":     map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{"name": "jazz"},
+	}
+}
+
+func (s *Server) open(uri, text string) *responseError {
+	doc := analyze(uri, text, s.capabilities)
+	s.docs[uri] = doc
+	return s.publish(uri, doc.diagnostics)
+}
+
+func (s *Server) publish(uri string, diagnostics []Diagnostic) *responseError {
+	if err := s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics}); err != nil {
+		return &responseError{Code: codeInvalidRequest, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, *responseError) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document not open: %s", uri)}
+	}
+	return doc, nil
+}
+
+func (s *Server) definition(params textDocumentPositionParams) (interface{}, *responseError) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	token := doc.identifierAt(params.Position)
+	if token == nil {
+		return nil, nil
+	}
+	decl := doc.symbols.Definition(token)
+	if decl == nil {
+		return nil, nil
+	}
+	return doc.location(decl), nil
+}
+
+func (s *Server) references(params referenceParams) (interface{}, *responseError) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := []Location{}
+	token := doc.identifierAt(params.Position)
+	if token == nil {
+		return locations, nil
+	}
+	decl := doc.symbols.Definition(token)
+	if decl == nil {
+		return locations, nil
+	}
+
+	if params.Context.IncludeDeclaration {
+		locations = append(locations, doc.location(decl))
+	}
+	for _, ref := range doc.symbols.References(decl) {
+		locations = append(locations, doc.location(ref))
+	}
+	return locations, nil
+}
+
+func (s *Server) hover(params textDocumentPositionParams) (interface{}, *responseError) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	token := doc.identifierAt(params.Position)
+	if token == nil {
+		return nil, nil
+	}
+
+	var signature, docs string
+	decl := doc.symbols.Definition(token)
+	switch {
+	case decl == nil:
+		native, ok := s.natives()[token.Lexeme]
+		if !ok {
+			return nil, nil
+		}
+		signature = nativeSignature(token.Lexeme, native)
+	case doc.funcs[decl] != nil:
+		signature = jazz.Signature(doc.funcs[decl])
+		docs = doc.funcs[decl].Doc
+	case doc.vars[decl] != nil:
+		keyword := "let"
+		if doc.vars[decl].Const {
+			keyword = "const"
+		}
+		signature = keyword + " " + decl.Lexeme
+		docs = doc.vars[decl].Doc
+	default:
+		signature = decl.Lexeme
+	}
+
+	value := "```jazz\n" + signature + "\n```"
+	if docs != "" {
+		value += "\n\n" + docs
+	}
+	return &Hover{Contents: markupContent{Kind: "markdown", Value: value}, Range: doc.tokenRange(token)}, nil
+}
+
+func (s *Server) documentSymbols(params documentSymbolParams) (interface{}, *responseError) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := []SymbolInformation{}
+	for _, stmt := range doc.stmts {
+		switch st := stmt.(type) {
+		case *jazz.FuncStmt:
+			symbols = append(symbols, SymbolInformation{Name: st.Name.Lexeme, Kind: symbolKindFunction, Location: doc.location(st.Name)})
+		case *jazz.VarStmt:
+			kind := symbolKindVariable
+			if st.Const {
+				kind = symbolKindConstant
+			}
+			for _, decl := range doc.symbols.Decls {
+				if doc.declaredBy(decl, st) {
+					symbols = append(symbols, SymbolInformation{Name: decl.Lexeme, Kind: kind, Location: doc.location(decl)})
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// declaredBy reports whether decl is one of the names bound by a top-level
+// let or const.
+func (doc *document) declaredBy(decl *jazz.Token, stmt *jazz.VarStmt) bool {
+	if stmt.Name != nil {
+		return decl == stmt.Name
+	}
+	return doc.symbols.Globals.Names[decl.Lexeme] == decl && inPattern(decl, stmt.Pattern)
+}
+
+func inPattern(name *jazz.Token, pattern jazz.Pattern) bool {
+	switch pat := pattern.(type) {
+	case *jazz.BindingPattern:
+		return pat.Name == name
+	case *jazz.ArrayPattern:
+		if pat.Rest == name {
+			return true
+		}
+		for _, el := range pat.Elements {
+			if inPattern(name, el) {
+				return true
+			}
+		}
+	case *jazz.MapPattern:
+		for _, entry := range pat.Entries {
+			if inPattern(name, entry.Pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) completion(params textDocumentPositionParams) (interface{}, *responseError) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	pos := doc.position(params.Position)
+	for _, decl := range doc.symbols.NamesAt(pos) {
+		seen[decl.Lexeme] = true
+		if fn := doc.funcs[decl]; fn != nil {
+			items = append(items, CompletionItem{Label: decl.Lexeme, Kind: completionKindFunction, Detail: jazz.Signature(fn)})
+		} else {
+			items = append(items, CompletionItem{Label: decl.Lexeme, Kind: completionKindVariable})
+		}
+	}
+
+	natives := s.natives()
+	names := make([]string, 0, len(natives))
+	for name := range natives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !seen[name] {
+			items = append(items, CompletionItem{Label: name, Kind: completionKindFunction, Detail: nativeSignature(name, natives[name])})
+		}
+	}
+
+	for _, keyword := range jazz.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKindKeyword})
+	}
+	return items, nil
+}
+
+func (s *Server) natives() map[string]jazz.Callable {
+	natives := map[string]jazz.Callable{}
+	for _, capability := range s.capabilities {
+		for name, native := range jazz.CapabilityNatives(capability) {
+			natives[name] = native
+		}
+	}
+	return natives
+}
+
+func nativeSignature(name string, native jazz.Callable) string {
+	arity := "..."
+	if native.Arity() >= 0 {
+		params := make([]string, native.Arity())
+		for n := range params {
+			params[n] = fmt.Sprintf("arg%d", n+1)
+		}
+		arity = strings.Join(params, ", ")
+	}
+	return fmt.Sprintf("native %s(%s)", name, arity)
+}
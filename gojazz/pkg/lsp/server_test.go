@@ -0,0 +1,143 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+const testURI = "file:///test.jz"
+
+// openServer returns a server with text open as testURI.
+func openServer(t *testing.T, text string) *Server {
+	t.Helper()
+	s := NewServer(strings.NewReader(""), io.Discard, jazz.DefaultCapabilities)
+	if err := s.open(testURI, text); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func at(line, character int) textDocumentPositionParams {
+	return textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}
+}
+
+func TestPositionsCountUTF16CodeUnits(t *testing.T) {
+	// 'é' is two bytes and one code unit, '😀' four bytes and two code units,
+	// so the 's' after them is at byte 28 but character 25.
+	s := openServer(t, `let s = "héllo😀"; print s;`)
+
+	result, err := s.definition(at(0, 25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result, (Location{URI: testURI, Range: span(0, 4, 5)}); got != want {
+		t.Errorf("definition = %v, want %v", got, want)
+	}
+
+	params := referenceParams{textDocumentPositionParams: at(0, 4)}
+	result, err = s.references(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result, []Location{{URI: testURI, Range: span(0, 25, 26)}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("references = %v, want %v", got, want)
+	}
+
+	hover, err := s.hover(at(0, 25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hover.(*Hover).Range, span(0, 25, 26); got != want {
+		t.Errorf("hover range = %v, want %v", got, want)
+	}
+}
+
+func TestDiagnosticsCountUTF16CodeUnits(t *testing.T) {
+	s := openServer(t, `{ let s = "😀"; let s = 1; }`)
+	diagnostics := s.docs[testURI].diagnostics
+	if len(diagnostics) != 1 {
+		b, _ := json.Marshal(diagnostics)
+		t.Fatalf("got diagnostics %s, want one", b)
+	}
+	if got, want := diagnostics[0].Range, span(0, 20, 21); got != want {
+		t.Errorf("range = %v, want %v", got, want)
+	}
+}
+
+const navigationText = `let total = 0;
+fn add(n) {
+    let total = n;
+    return total;
+}
+total = add(total);
+print len("total");
+`
+
+func TestDefinition(t *testing.T) {
+	s := openServer(t, navigationText)
+
+	tests := []struct {
+		name string
+		at   textDocumentPositionParams
+		want interface{}
+	}{
+		{"a global use", at(5, 14), Location{URI: testURI, Range: span(0, 4, 9)}},
+		{"a global assignment", at(5, 0), Location{URI: testURI, Range: span(0, 4, 9)}},
+		{"a local shadowing the global", at(3, 12), Location{URI: testURI, Range: span(2, 8, 13)}},
+		{"a parameter", at(2, 16), Location{URI: testURI, Range: span(1, 7, 8)}},
+		{"a function", at(5, 8), Location{URI: testURI, Range: span(1, 3, 6)}},
+		{"the end of a name", at(5, 11), Location{URI: testURI, Range: span(1, 3, 6)}},
+		{"a native", at(6, 6), nil},
+		{"no name", at(6, 12), nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			got, err := s.definition(test.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	s := openServer(t, navigationText)
+
+	params := referenceParams{textDocumentPositionParams: at(0, 4)}
+	got, err := s.references(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Location{{URI: testURI, Range: span(5, 0, 5)}, {URI: testURI, Range: span(5, 12, 17)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	params = referenceParams{textDocumentPositionParams: at(3, 12)}
+	params.Context.IncludeDeclaration = true
+	got, err = s.references(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Location{{URI: testURI, Range: span(2, 8, 13)}, {URI: testURI, Range: span(3, 11, 16)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,245 @@
+// Package profile measures where a Jazz program spends its time, per function
+// and per source line. A Profiler is an interpreter hook: it times the gaps
+// between statements and charges them to the running line and call stack.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thepatrik/jazz/gojazz/pkg/jazz"
+)
+
+// Func is the time spent in a function. Total includes the functions it
+// calls, counting recursive calls once.
+type Func struct {
+	Name  string // name and line of the declaration, such as "fib:1"
+	Calls int
+	Self  time.Duration
+	Total time.Duration
+}
+
+// Line is the time spent running the statements on a source line, counting
+// binding the arguments of the functions they call but not running them.
+type Line struct {
+	Line int
+	Runs int // statements started on the line
+	Time time.Duration
+}
+
+// frame is an active call.
+type frame struct {
+	fn    *Func
+	line  int
+	path  string // folded stack, such as "<script>;main:3;fib:1"
+	start time.Time
+	outer bool // outermost active call of fn, see Func.Total
+}
+
+type Profiler struct {
+	start  time.Time
+	last   time.Time
+	script *Func
+	stack  []*frame
+	funcs  map[*jazz.Token]*Func // by declaration name
+	active map[*Func]int
+	lines  map[int]*Line
+	stacks map[string]time.Duration // self time by folded stack
+}
+
+// New returns a profiler. Timing starts with the first statement, so that
+// scanning, parsing and resolving are left out.
+func New() *Profiler {
+	script := &Func{Name: "<script>", Calls: 1}
+	return &Profiler{
+		script: script,
+		stack:  []*frame{{fn: script, path: script.Name, outer: true}},
+		funcs:  map[*jazz.Token]*Func{},
+		active: map[*Func]int{},
+		lines:  map[int]*Line{},
+		stacks: map[string]time.Duration{},
+	}
+}
+
+// tick charges the time since the last event to the running line and stack.
+func (p *Profiler) tick() time.Time {
+	now := time.Now()
+	if p.last.IsZero() {
+		p.start, p.last, p.stack[0].start = now, now, now
+	}
+	elapsed := now.Sub(p.last)
+	p.last = now
+
+	top := p.stack[len(p.stack)-1]
+	top.fn.Self += elapsed
+	p.stacks[top.path] += elapsed
+	if top.line > 0 {
+		p.line(top.line).Time += elapsed
+	}
+	return now
+}
+
+func (p *Profiler) line(n int) *Line {
+	line, ok := p.lines[n]
+	if !ok {
+		line = &Line{Line: n}
+		p.lines[n] = line
+	}
+	return line
+}
+
+// Before implements jazz.Hook.
+func (p *Profiler) Before(_ *jazz.Interpreter, stmt jazz.Stmt) error {
+	p.tick()
+	top := p.stack[len(p.stack)-1]
+	top.line = jazz.StmtLine(stmt)
+	p.line(top.line).Runs++
+	return nil
+}
+
+// Call implements jazz.CallHook.
+func (p *Profiler) Call(_ *jazz.Interpreter, fn *jazz.Func) {
+	now := p.tick()
+
+	name := fn.Declaration.Name
+	f, ok := p.funcs[name]
+	if !ok {
+		f = &Func{Name: fmt.Sprintf("%s:%d", name.Lexeme, name.Line)}
+		p.funcs[name] = f
+	}
+	f.Calls++
+
+	top := p.stack[len(p.stack)-1]
+	// Binding the arguments is charged to the line of the call.
+	p.stack = append(p.stack, &frame{fn: f, line: top.line, path: top.path + ";" + f.Name, start: now, outer: p.active[f] == 0})
+	p.active[f]++
+}
+
+// Return implements jazz.CallHook.
+func (p *Profiler) Return(_ *jazz.Interpreter, _ *jazz.Func) {
+	now := p.tick()
+
+	top := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.active[top.fn]--
+	if top.outer {
+		top.fn.Total += now.Sub(top.start)
+	}
+}
+
+// Stop ends the profile once the program has finished.
+func (p *Profiler) Stop() {
+	now := p.tick()
+	p.script.Total = now.Sub(p.start)
+}
+
+// Elapsed returns how long the program ran.
+func (p *Profiler) Elapsed() time.Duration {
+	return p.script.Total
+}
+
+// Funcs returns the functions that were called, and the top-level script,
+// slowest first by self time.
+func (p *Profiler) Funcs() []*Func {
+	funcs := []*Func{p.script}
+	for _, f := range p.funcs {
+		funcs = append(funcs, f)
+	}
+	sort.Slice(funcs, func(i, j int) bool {
+		if funcs[i].Self != funcs[j].Self {
+			return funcs[i].Self > funcs[j].Self
+		}
+		return funcs[i].Name < funcs[j].Name
+	})
+	return funcs
+}
+
+// Lines returns the lines that ran, slowest first.
+func (p *Profiler) Lines() []*Line {
+	lines := make([]*Line, 0, len(p.lines))
+	for _, line := range p.lines {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Time != lines[j].Time {
+			return lines[i].Time > lines[j].Time
+		}
+		return lines[i].Line < lines[j].Line
+	})
+	return lines
+}
+
+// WriteFolded writes the self time of every call stack in microseconds, one
+// "frame;frame;frame value" line per stack. This is the input format of
+// flamegraph.pl and of most flame graph viewers, such as speedscope.
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	paths := make([]string, 0, len(p.stacks))
+	for path := range p.stacks {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		micros := p.stacks[path].Microseconds()
+		if micros == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", path, micros); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTable writes the top n functions and lines. source is the program, for
+// showing the slowest lines.
+func (p *Profiler) WriteTable(w io.Writer, n int, source string) error {
+	elapsed := p.Elapsed()
+	percent := func(d time.Duration) float64 {
+		if elapsed == 0 {
+			return 0
+		}
+		return 100 * float64(d) / float64(elapsed)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "total time %s\n\n", Round(elapsed))
+	fmt.Fprintf(&b, "%7s %10s %10s %10s  %s\n", "self%", "self", "total", "calls", "function")
+	for i, f := range p.Funcs() {
+		if i == n {
+			break
+		}
+		fmt.Fprintf(&b, "%6.2f%% %10s %10s %10d  %s\n", percent(f.Self), Round(f.Self), Round(f.Total), f.Calls, f.Name)
+	}
+
+	text := strings.Split(source, "\n")
+	fmt.Fprintf(&b, "\n%7s %10s %10s  %s\n", "time%", "time", "runs", "line")
+	for i, line := range p.Lines() {
+		if i == n {
+			break
+		}
+		code := ""
+		if line.Line >= 1 && line.Line <= len(text) {
+			code = strings.TrimSpace(text[line.Line-1])
+		}
+		fmt.Fprintf(&b, "%6.2f%% %10s %10d  %d: %s\n", percent(line.Time), Round(line.Time), line.Runs, line.Line, code)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Round rounds a duration for display: to milliseconds from a second up, to
+// microseconds from a millisecond up.
+func Round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	}
+	return d
+}